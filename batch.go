@@ -0,0 +1,178 @@
+package mysqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sillyhatxu/db-client/builder"
+	"time"
+)
+
+const (
+	// maxPlaceholders is the limit of placeholders in one MySQL statement.
+	maxPlaceholders = 65535
+	// defaultMaxPacketSize matches the maxAllowedPacket of dbclient.
+	defaultMaxPacketSize = 4 << 20
+	// valueOverhead covers the placeholder text and the header of a value.
+	valueOverhead = 4
+)
+
+type BatchOptions struct {
+	// MaxRows caps the number of rows of one INSERT, 0 leaves it to the
+	// other limits.
+	MaxRows int
+	// MaxPacketSize caps the estimated size of one INSERT in bytes, it should
+	// not exceed the max_allowed_packet of the server. The default is 4MB.
+	MaxPacketSize int
+	// Transaction runs all the INSERTs in one transaction.
+	Transaction bool
+}
+
+type BatchResult struct {
+	RowsAffected int64
+	// FirstIds holds the LAST_INSERT_ID() of every INSERT, the id of its
+	// first row.
+	FirstIds []int64
+}
+
+// InsertBatch inserts rows into table with as few INSERT statements as the
+// placeholder limit, opts.MaxRows and opts.MaxPacketSize allow. Every row
+// must have the same columns. Without opts.Transaction the INSERTs done
+// before a failure stay and are reported in the result.
+func (mc *MysqlClient) InsertBatch(table string, rows []map[string]interface{}, opts BatchOptions) (BatchResult, error) {
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.InsertBatchContext(ctx, table, rows, opts)
+}
+
+func (mc *MysqlClient) InsertBatchContext(ctx context.Context, table string, rows []map[string]interface{}, opts BatchOptions) (BatchResult, error) {
+	if err := checkBatchRows(rows); err != nil {
+		return BatchResult{}, err
+	}
+	chunks := chunkBatch(table, rows, opts)
+	if !opts.Transaction {
+		return insertChunks(ctx, mc, table, chunks)
+	}
+	var result BatchResult
+	err := mc.WithTxContext(ctx, func(ctx context.Context, tx *Tx) error {
+		var err error
+		result, err = insertChunks(ctx, tx, table, chunks)
+		return err
+	})
+	if err != nil {
+		return BatchResult{}, err
+	}
+	return result, nil
+}
+
+func insertChunks(ctx context.Context, session Session, table string, chunks [][]map[string]interface{}) (BatchResult, error) {
+	var result BatchResult
+	for _, chunk := range chunks {
+		sql, args, err := builder.BuildInsert(table, chunk)
+		if err != nil {
+			return result, err
+		}
+		res, err := session.ExecContext(ctx, sql, args...)
+		if err != nil {
+			return result, err
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return result, err
+		}
+		firstId, err := res.LastInsertId()
+		if err != nil {
+			return result, err
+		}
+		result.RowsAffected += rowsAffected
+		result.FirstIds = append(result.FirstIds, firstId)
+	}
+	return result, nil
+}
+
+// checkBatchRows makes sure every row has the columns of the first one, as
+// the chunks are sized from it.
+func checkBatchRows(rows []map[string]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if len(rows[0]) == 0 {
+		return errors.New("batch row 0 has no columns")
+	}
+	for i, row := range rows[1:] {
+		if len(row) != len(rows[0]) {
+			return fmt.Errorf("batch row %d has %d columns, row 0 has %d", i+1, len(row), len(rows[0]))
+		}
+		for column := range row {
+			if _, ok := rows[0][column]; !ok {
+				return fmt.Errorf("batch row %d has column %s that row 0 doesn't have", i+1, column)
+			}
+		}
+	}
+	return nil
+}
+
+// chunkBatch splits rows so that no chunk goes over the placeholder limit,
+// opts.MaxRows or the estimated opts.MaxPacketSize. A row bigger than the
+// packet size on its own still gets a chunk, the server decides on it.
+func chunkBatch(table string, rows []map[string]interface{}, opts BatchOptions) [][]map[string]interface{} {
+	if len(rows) == 0 {
+		return nil
+	}
+	maxRows := maxPlaceholders / len(rows[0])
+	if opts.MaxRows > 0 && opts.MaxRows < maxRows {
+		maxRows = opts.MaxRows
+	}
+	maxPacketSize := opts.MaxPacketSize
+	if maxPacketSize <= 0 {
+		maxPacketSize = defaultMaxPacketSize
+	}
+	headerSize := len("INSERT INTO  () VALUES ") + len(table)
+	for column := range rows[0] {
+		headerSize += len(column) + 1
+	}
+	var chunks [][]map[string]interface{}
+	start, size := 0, headerSize
+	for i, row := range rows {
+		rowSize := estimateRowSize(row)
+		if i > start && (i-start >= maxRows || size+rowSize > maxPacketSize) {
+			chunks = append(chunks, rows[start:i])
+			start, size = i, headerSize
+		}
+		size += rowSize
+	}
+	return append(chunks, rows[start:])
+}
+
+func estimateRowSize(row map[string]interface{}) int {
+	size := 0
+	for _, value := range row {
+		size += estimateValueSize(value) + valueOverhead
+	}
+	return size
+}
+
+// estimateValueSize is the size of a value once escaped in the statement,
+// strings and bytes are counted twice for the worst case escaping.
+func estimateValueSize(value interface{}) int {
+	switch v := value.(type) {
+	case nil:
+		return len("NULL")
+	case string:
+		return 2 * len(v)
+	case []byte:
+		return 2 * len(v)
+	case *string:
+		if v == nil {
+			return len("NULL")
+		}
+		return 2 * len(*v)
+	case bool, int8, uint8:
+		return 1
+	case int, int64, uint, uint64, float64, int32, uint32, float32, int16, uint16:
+		return 20
+	case time.Time, *time.Time:
+		return len("'2006-01-02 15:04:05.999999'")
+	}
+	return len(fmt.Sprint(value))
+}
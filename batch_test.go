@@ -0,0 +1,73 @@
+package mysqlclient
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func newBatchRows(n int, columns int, value interface{}) []map[string]interface{} {
+	rows := make([]map[string]interface{}, n)
+	for i := range rows {
+		rows[i] = make(map[string]interface{}, columns)
+		for c := 0; c < columns; c++ {
+			rows[i][string(rune('a'+c))] = value
+		}
+	}
+	return rows
+}
+
+func chunkSizes(chunks [][]map[string]interface{}) []int {
+	var sizes []int
+	for _, chunk := range chunks {
+		sizes = append(sizes, len(chunk))
+	}
+	return sizes
+}
+
+func TestChunkBatch(t *testing.T) {
+	var testCases = []struct {
+		rows     []map[string]interface{}
+		opts     BatchOptions
+		expected []int
+	}{
+		{rows: nil, expected: nil},
+		{rows: newBatchRows(10, 2, 1), expected: []int{10}},
+		{rows: newBatchRows(10, 2, 1), opts: BatchOptions{MaxRows: 4}, expected: []int{4, 4, 2}},
+		// 65535 / 5 = 13107 rows per INSERT
+		{rows: newBatchRows(30000, 5, 1), opts: BatchOptions{MaxPacketSize: 1 << 30}, expected: []int{13107, 13107, 3786}},
+		// every row is 2 * 100 + 4 bytes
+		{rows: newBatchRows(10, 1, strings.Repeat("x", 100)), opts: BatchOptions{MaxPacketSize: 700}, expected: []int{3, 3, 3, 1}},
+		{rows: newBatchRows(2, 1, strings.Repeat("x", 1000)), opts: BatchOptions{MaxPacketSize: 700}, expected: []int{1, 1}},
+	}
+	for _, test := range testCases {
+		assert.EqualValues(t, test.expected, chunkSizes(chunkBatch("user", test.rows, test.opts)))
+	}
+}
+
+func TestCheckBatchRows(t *testing.T) {
+	var testCases = []struct {
+		rows []map[string]interface{}
+		err  string
+	}{
+		{rows: nil},
+		{rows: newBatchRows(3, 2, 1)},
+		{rows: []map[string]interface{}{{}}, err: "batch row 0 has no columns"},
+		{rows: []map[string]interface{}{{"a": 1}, {"a": 1, "b": 2}}, err: "batch row 1 has 2 columns, row 0 has 1"},
+		{rows: []map[string]interface{}{{"a": 1}, {"a": 1}, {"b": 2}}, err: "batch row 2 has column b that row 0 doesn't have"},
+	}
+	for _, test := range testCases {
+		err := checkBatchRows(test.rows)
+		if test.err == "" {
+			assert.Nil(t, err)
+		} else {
+			assert.EqualError(t, err, test.err)
+		}
+	}
+	mc := &MysqlClient{}
+	_, err := mc.InsertBatchContext(context.Background(), "user", []map[string]interface{}{{}}, BatchOptions{})
+	assert.EqualError(t, err, "batch row 0 has no columns")
+	_, err = mc.InsertBatchContext(context.Background(), "user", []map[string]interface{}{{"a": 1}, {"b": 1}}, BatchOptions{})
+	assert.NotNil(t, err)
+}
@@ -167,6 +167,69 @@ func resolveHaving(having interface{}) (map[string]interface{}, error) {
 	return copiedMap, nil
 }
 
+// SelectQuery describes a SELECT for BuildSelectQuery, an alternative to
+// the special keys of BuildSelect. Where and Having are joined with AND.
+type SelectQuery struct {
+	Table   string
+	Fields  []string
+	Where   []Comparable
+	GroupBy string
+	Having  []Comparable
+	OrderBy string
+	// Limit is nil for no LIMIT, or {count} or {offset, count}.
+	Limit []uint
+	// LockMode is "", "share" or "exclusive".
+	LockMode string
+}
+
+// BuildSelectQuery works as BuildSelect but takes the conditions as
+// Comparables, see Conditions to get them from a where map.
+func BuildSelectQuery(query SelectQuery) (string, []interface{}, error) {
+	var limit *eleLimit
+	switch len(query.Limit) {
+	case 0:
+	case 1:
+		limit = &eleLimit{begin: 0, step: query.Limit[0]}
+	case 2:
+		limit = &eleLimit{begin: query.Limit[0], step: query.Limit[1]}
+	default:
+		return "", nil, errLimitValueLength
+	}
+	lockMode := strings.TrimSpace(query.LockMode)
+	if _, ok := allowedLockMode[lockMode]; lockMode != "" && !ok {
+		return "", nil, errNotAllowedLockMode
+	}
+	conditions := append([]Comparable(nil), query.Where...)
+	if len(query.Having) > 0 && strings.TrimSpace(query.GroupBy) != "" {
+		conditions = append(conditions, nilComparable(0))
+		conditions = append(conditions, query.Having...)
+	}
+	fields := append([]string(nil), query.Fields...)
+	return buildSelect(query.Table, fields, strings.TrimSpace(query.GroupBy), strings.TrimSpace(query.OrderBy), lockMode, limit, conditions...)
+}
+
+// Conditions turns a where map into Comparables. It supports the same keys
+// as BuildSelect except the special ones, _or aside.
+func Conditions(where map[string]interface{}) ([]Comparable, error) {
+	for key := range where {
+		switch key {
+		case "_orderby", "_groupby", "_having", "_limit", "_lockMode":
+			return nil, fmt.Errorf("[builder] %s is not a condition", key)
+		}
+	}
+	return getWhereConditions(where)
+}
+
+// BuildUpdateWhere works as BuildUpdate with the conditions as Comparables.
+func BuildUpdateWhere(table string, update map[string]interface{}, conditions ...Comparable) (string, []interface{}, error) {
+	return buildUpdate(table, update, conditions...)
+}
+
+// BuildDeleteWhere works as BuildDelete with the conditions as Comparables.
+func BuildDeleteWhere(table string, conditions ...Comparable) (string, []interface{}, error) {
+	return buildDelete(table, conditions...)
+}
+
 // BuildUpdate work as its name says
 func BuildUpdate(table string, where map[string]interface{}, update map[string]interface{}) (string, []interface{}, error) {
 	conditions, err := getWhereConditions(where)
@@ -190,6 +253,12 @@ func BuildInsert(table string, data []map[string]interface{}) (string, []interfa
 	return buildInsert(table, data, commonInsert)
 }
 
+// BuildInsertDefaults inserts n rows made only of the column defaults, as
+// INSERT INTO table () VALUES (),().
+func BuildInsertDefaults(table string, n int) (string, []interface{}, error) {
+	return buildInsertDefaults(table, n)
+}
+
 // BuildInsertIgnore work as its name says
 func BuildInsertIgnore(table string, data []map[string]interface{}) (string, []interface{}, error) {
 	return buildInsert(table, data, ignoreInsert)
@@ -200,6 +269,14 @@ func BuildReplaceInsert(table string, data []map[string]interface{}) (string, []
 	return buildInsert(table, data, replaceInsert)
 }
 
+// BuildUpsert builds INSERT ... ON DUPLICATE KEY UPDATE. The keys of update
+// are the columns set when the row already exists, its values may be
+// Values(col), Raw(expr), Incr(n) or a plain value bound as a placeholder.
+// An empty update sets every inserted column to its new value.
+func BuildUpsert(table string, data []map[string]interface{}, update map[string]interface{}) (string, []interface{}, error) {
+	return buildUpsert(table, data, update)
+}
+
 func isStringInSlice(str string, arr []string) bool {
 	for _, s := range arr {
 		if s == str {
@@ -363,6 +363,75 @@ func Test_BuildInsert(t *testing.T) {
 	}
 }
 
+func Test_BuildUpsert(t *testing.T) {
+	ass := assert.New(t)
+	type inStruct struct {
+		table   string
+		setData []map[string]interface{}
+		update  map[string]interface{}
+	}
+	type outStruct struct {
+		cond string
+		vals []interface{}
+		err  error
+	}
+	var data = []struct {
+		in  inStruct
+		out outStruct
+	}{
+		{
+			in: inStruct{
+				table: "tb",
+				setData: []map[string]interface{}{
+					{"id": 1, "name": "foo", "visits": 1},
+				},
+			},
+			out: outStruct{
+				cond: "INSERT INTO tb (id,name,visits) VALUES (?,?,?) ON DUPLICATE KEY UPDATE id=VALUES(id),name=VALUES(name),visits=VALUES(visits)",
+				vals: []interface{}{1, "foo", 1},
+				err:  nil,
+			},
+		},
+		{
+			in: inStruct{
+				table: "tb",
+				setData: []map[string]interface{}{
+					{"id": 1, "name": "foo", "visits": 1},
+					{"id": 2, "name": "bar", "visits": 1},
+				},
+				update: map[string]interface{}{
+					"name":          Values("name"),
+					"visits":        Incr(1),
+					"modified_time": Raw("NOW()"),
+					"status":        "active",
+				},
+			},
+			out: outStruct{
+				cond: "INSERT INTO tb (id,name,visits) VALUES (?,?,?),(?,?,?) ON DUPLICATE KEY UPDATE modified_time=NOW(),name=VALUES(name),status=?,visits=visits+?",
+				vals: []interface{}{1, "foo", 1, 2, "bar", 1, "active", 1},
+				err:  nil,
+			},
+		},
+		{
+			in: inStruct{
+				table:   "tb",
+				setData: nil,
+			},
+			out: outStruct{
+				cond: "",
+				vals: nil,
+				err:  errInsertNullData,
+			},
+		},
+	}
+	for _, tc := range data {
+		cond, vals, err := BuildUpsert(tc.in.table, tc.in.setData, tc.in.update)
+		ass.Equal(tc.out.err, err)
+		ass.Equal(tc.out.cond, cond)
+		ass.Equal(tc.out.vals, vals)
+	}
+}
+
 func Test_BuildDelete(t *testing.T) {
 	ass := assert.New(t)
 	type inStruct struct {
@@ -1254,3 +1323,73 @@ func TestFixBug_insert_quote_field(t *testing.T) {
 	ass.Equal("INSERT INTO tb (`id`,`order`,id) VALUES (?,?,?)", cond)
 	ass.Equal([]interface{}{3, 2, 1}, vals)
 }
+
+func Test_BuildSelectQuery(t *testing.T) {
+	ass := assert.New(t)
+	var data = []struct {
+		in   SelectQuery
+		cond string
+		vals []interface{}
+		err  error
+	}{
+		{
+			in:   SelectQuery{Table: "tb"},
+			cond: "SELECT * FROM tb",
+		},
+		{
+			in: SelectQuery{
+				Table:    "tb",
+				Fields:   []string{"id", "name"},
+				Where:    []Comparable{Eq{"age": 23}, Like{"name": "%foo%"}},
+				OrderBy:  "id DESC",
+				Limit:    []uint{10, 20},
+				LockMode: "exclusive",
+			},
+			cond: "SELECT id,name FROM tb WHERE (age=? AND name LIKE ?) ORDER BY id DESC LIMIT ?,? FOR UPDATE",
+			vals: []interface{}{23, "%foo%", 10, 20},
+		},
+		{
+			in: SelectQuery{
+				Table:   "tb",
+				Fields:  []string{"city", "count(1) as total"},
+				GroupBy: "city",
+				Having:  []Comparable{Gt{"total": 10}},
+				Limit:   []uint{5},
+			},
+			cond: "SELECT city,count(1) as total FROM tb GROUP BY city HAVING (total>?) LIMIT ?,?",
+			vals: []interface{}{10, 0, 5},
+		},
+		{
+			in:  SelectQuery{Table: "tb", Limit: []uint{1, 2, 3}},
+			err: errLimitValueLength,
+		},
+		{
+			in:  SelectQuery{Table: "tb", LockMode: "all"},
+			err: errNotAllowedLockMode,
+		},
+	}
+	for _, tc := range data {
+		cond, vals, err := BuildSelectQuery(tc.in)
+		ass.Equal(tc.err, err)
+		ass.Equal(tc.cond, cond)
+		ass.Equal(tc.vals, vals)
+	}
+}
+
+func TestConditions(t *testing.T) {
+	ass := assert.New(t)
+	conditions, err := Conditions(map[string]interface{}{"age >": 20, "name": "foo"})
+	ass.Nil(err)
+	cond, vals, err := BuildDeleteWhere("tb", conditions...)
+	ass.Nil(err)
+	ass.Equal("DELETE FROM tb WHERE (name=? AND age>?)", cond)
+	ass.Equal([]interface{}{"foo", 20}, vals)
+
+	cond, vals, err = BuildUpdateWhere("tb", map[string]interface{}{"name": "bar"}, conditions...)
+	ass.Nil(err)
+	ass.Equal("UPDATE tb SET name=? WHERE (name=? AND age>?)", cond)
+	ass.Equal([]interface{}{"bar", "foo", 20}, vals)
+
+	_, err = Conditions(map[string]interface{}{"_orderby": "id"})
+	ass.NotNil(err)
+}
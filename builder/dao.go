@@ -171,6 +171,34 @@ func (g Gte) Build() ([]string, []interface{}) {
 	return build(g, ">=")
 }
 
+//Seek is the keyset pagination condition (a,b)>(?,?), or (a,b)<(?,?)
+//when Desc, for rows ordered by Columns. Values holds a value per column.
+type Seek struct {
+	Columns []string
+	Values  []interface{}
+	Desc    bool
+}
+
+//Build implements the Comparable interface
+func (s Seek) Build() ([]string, []interface{}) {
+	if len(s.Columns) == 0 || len(s.Columns) != len(s.Values) {
+		return nil, nil
+	}
+	op := ">"
+	if s.Desc {
+		op = "<"
+	}
+	fields := make([]string, len(s.Columns))
+	for i, column := range s.Columns {
+		fields[i] = quoteField(column)
+	}
+	if len(fields) == 1 {
+		return []string{fields[0] + op + "?"}, append([]interface{}(nil), s.Values...)
+	}
+	cond := fmt.Sprintf("(%s)%s%s", strings.Join(fields, ","), op, createMultiPlaceholders(len(fields)))
+	return []string{cond}, append([]interface{}(nil), s.Values...)
+}
+
 //In means in
 type In map[string][]interface{}
 
@@ -397,6 +425,80 @@ func buildInsert(table string, setMap []map[string]interface{}, insertType inser
 	return fmt.Sprintf(format, insertType, quoteField(table), strings.Join(fields, ","), strings.Join(sets, ",")), vals, nil
 }
 
+func buildInsertDefaults(table string, n int) (string, []interface{}, error) {
+	if n < 1 {
+		return "", nil, errInsertNullData
+	}
+	values := strings.TrimRight(strings.Repeat("(),", n), ",")
+	return fmt.Sprintf("%s %s () VALUES %s", commonInsert, quoteField(table), values), nil, nil
+}
+
+// UpsertExpr is an expression of the UPDATE part of BuildUpsert.
+type UpsertExpr interface {
+	upsert(field string) (string, []interface{})
+}
+
+type valuesExpr string
+
+func (v valuesExpr) upsert(field string) (string, []interface{}) {
+	return fmt.Sprintf("%s=VALUES(%s)", quoteField(field), quoteField(string(v))), nil
+}
+
+// Values refers to the value the INSERT tried to put in column.
+func Values(column string) UpsertExpr {
+	return valuesExpr(column)
+}
+
+type rawExpr string
+
+func (r rawExpr) upsert(field string) (string, []interface{}) {
+	return fmt.Sprintf("%s=%s", quoteField(field), string(r)), nil
+}
+
+// Raw is a SQL expression written as is, it must not hold user input.
+func Raw(expr string) UpsertExpr {
+	return rawExpr(expr)
+}
+
+type incrExpr struct {
+	n interface{}
+}
+
+func (i incrExpr) upsert(field string) (string, []interface{}) {
+	return fmt.Sprintf("%s=%s+?", quoteField(field), quoteField(field)), []interface{}{i.n}
+}
+
+// Incr adds n to the current value of the column.
+func Incr(n interface{}) UpsertExpr {
+	return incrExpr{n: n}
+}
+
+func buildUpsert(table string, setMap []map[string]interface{}, update map[string]interface{}) (string, []interface{}, error) {
+	cond, vals, err := buildInsert(table, setMap, commonInsert)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(update) == 0 {
+		update = make(map[string]interface{}, len(setMap[0]))
+		for field := range setMap[0] {
+			update[field] = Values(field)
+		}
+	}
+	keys, updateVals := resolveKV(update)
+	sets := make([]string, 0, len(keys))
+	for i, k := range keys {
+		if expr, ok := updateVals[i].(UpsertExpr); ok {
+			set, args := expr.upsert(k)
+			sets = append(sets, set)
+			vals = append(vals, args...)
+			continue
+		}
+		sets = append(sets, assembleExpression(k, "="))
+		vals = append(vals, updateVals[i])
+	}
+	return cond + " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ","), vals, nil
+}
+
 func buildUpdate(table string, update map[string]interface{}, conditions ...Comparable) (string, []interface{}, error) {
 	format := "UPDATE %s SET %s"
 	keys, vals := resolveKV(update)
@@ -54,6 +54,36 @@ func TestIn(t *testing.T) {
 	}
 }
 
+func TestSeek(t *testing.T) {
+	var testData = []struct {
+		in      Seek
+		outCond []string
+		outVals []interface{}
+	}{
+		{
+			in:      Seek{Columns: []string{"created_time", "id"}, Values: []interface{}{"2020-01-01", 7}},
+			outCond: []string{"(created_time,id)>(?,?)"},
+			outVals: []interface{}{"2020-01-01", 7},
+		},
+		{
+			in:      Seek{Columns: []string{"id"}, Values: []interface{}{7}, Desc: true},
+			outCond: []string{"id<?"},
+			outVals: []interface{}{7},
+		},
+		{
+			in:      Seek{Columns: []string{"id"}},
+			outCond: nil,
+			outVals: nil,
+		},
+	}
+	ass := assert.New(t)
+	for _, testCase := range testData {
+		cond, vals := testCase.in.Build()
+		ass.Equal(testCase.outCond, cond)
+		ass.Equal(testCase.outVals, vals)
+	}
+}
+
 func TestNestWhere(t *testing.T) {
 	var testData = []struct {
 		in      NestWhere
@@ -257,6 +287,16 @@ func TestBuildInsert(t *testing.T) {
 	}
 }
 
+func TestBuildInsertDefaults(t *testing.T) {
+	ass := assert.New(t)
+	sql, vals, err := buildInsertDefaults("tb1", 3)
+	ass.Nil(err)
+	ass.Equal("INSERT INTO tb1 () VALUES (),(),()", sql)
+	ass.Nil(vals)
+	_, _, err = buildInsertDefaults("tb1", 0)
+	ass.Equal(errInsertNullData, err)
+}
+
 func TestBuildUpdate(t *testing.T) {
 	var data = []struct {
 		table      string
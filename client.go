@@ -1,9 +1,12 @@
 package mysqlclient
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/sillyhatxu/db-client/metrics"
 	"sync"
 	"time"
 )
@@ -14,22 +17,50 @@ var (
 )
 
 type MysqlClient struct {
-	config *Config
-	mu     sync.Mutex
+	config   *Config
+	mu       sync.Mutex
+	replicas *replicaSet
+	stmts    *stmtCache
+	health   *healthMonitor
+	gate     *callGate
+	kills    *killConns
+	// unregister drops the pool collectors of the Metrics option.
+	unregister []func()
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
 }
 
 func NewMysqlClient(opts ...Option) (*MysqlClient, error) {
 	//default
 	config := &Config{
-		ddlPath: "",
-		flyway:  false,
-		timeout: 10 * time.Second,
+		ddlPath:     "",
+		flyway:      false,
+		timeout:     10 * time.Second,
+		directScan:  true,
+		tracer:      NoopTracer{},
+		stmtCache:   defaultStmtCacheSize,
+		maxPageSize: defaultMaxPageSize,
+
+		replicaCheckInterval: defaultReplicaCheckInterval,
 	}
 	for _, opt := range opts {
 		opt(config)
 	}
 	mc := &MysqlClient{
 		config: config,
+		gate:   newCallGate(),
+		stopCh: make(chan struct{}),
+	}
+	if len(config.cursorSecret) == 0 {
+		config.cursorSecret = newCursorSecret()
+	}
+	if config.stmtCache > 0 {
+		mc.stmts = newStmtCache(config.stmtCache)
+	}
+	if config.killOnTimeout {
+		mc.kills = newKillConns()
 	}
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
@@ -37,13 +68,62 @@ func NewMysqlClient(opts ...Option) (*MysqlClient, error) {
 	if err != nil {
 		return nil, err
 	}
+	if config.metrics != nil {
+		mc.unregister = append(mc.unregister, metrics.RegisterDBStats(config.metrics, config.pool, config.metricsClient, primaryPoolName))
+		for i, pool := range config.replicas {
+			mc.unregister = append(mc.unregister, metrics.RegisterDBStats(config.metrics, pool, config.metricsClient, fmt.Sprintf("%s%d", replicaPoolPrefix, i)))
+		}
+	}
 	err = mc.initialFlayway()
 	if err != nil {
+		mc.unregisterMetrics()
 		return nil, err
 	}
+	if len(config.replicas) > 0 {
+		mc.replicas = newReplicaSet(config.replicas, config.routing)
+		mc.checkReplicas()
+		mc.runBackground(config.replicaCheckInterval, mc.checkReplicas)
+	}
+	if config.healthCheck != nil {
+		mc.health = newHealthMonitor(*config.healthCheck)
+		mc.checkHealth()
+		mc.runBackground(mc.health.config.Interval, mc.checkHealth)
+	}
 	return mc, nil
 }
 
+func (mc *MysqlClient) unregisterMetrics() {
+	for _, unregister := range mc.unregister {
+		unregister()
+	}
+	mc.unregister = nil
+}
+
+// runBackground runs fn every interval until stopBackground is called.
+func (mc *MysqlClient) runBackground(interval time.Duration, fn func()) {
+	mc.wg.Add(1)
+	go func() {
+		defer mc.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-mc.stopCh:
+				return
+			case <-ticker.C:
+				fn()
+			}
+		}
+	}()
+}
+
+func (mc *MysqlClient) stopBackground() {
+	mc.stopOnce.Do(func() {
+		close(mc.stopCh)
+	})
+	mc.wg.Wait()
+}
+
 func (mc *MysqlClient) validate() error {
 	if mc.config == nil {
 		return CheckConfigNilError
@@ -51,17 +131,37 @@ func (mc *MysqlClient) validate() error {
 	if mc.config.pool == nil {
 		return CheckDBPoolError
 	}
+	if mc.config.killOnTimeout {
+		if err := checkKillPools(append([]*sql.DB{mc.config.pool}, mc.config.replicas...)...); err != nil {
+			return err
+		}
+	}
 	return mc.Ping()
 }
 
 func (mc *MysqlClient) Ping() error {
-	return mc.GetDB().Ping()
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.PingContext(ctx)
+}
+
+func (mc *MysqlClient) PingContext(ctx context.Context) error {
+	return wrapError(mc.GetDB().PingContext(ctx))
 }
 
 func (mc *MysqlClient) GetDB() *sql.DB {
 	return mc.config.pool
 }
 
+// GetTransaction begins a new transaction on its own connection, it never
+// nests in the transaction of a callback.
 func (mc *MysqlClient) GetTransaction() (*sql.Tx, error) {
 	return mc.GetDB().Begin()
 }
+
+// GetTransactionContext begins a transaction bound to ctx, which the caller
+// must cancel once the transaction is finished.
+func (mc *MysqlClient) GetTransactionContext(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	tx, err := mc.GetDB().BeginTx(ctx, opts)
+	return tx, wrapError(err)
+}
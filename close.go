@@ -0,0 +1,101 @@
+package mysqlclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrClientClosed = errors.New("client is closed")
+
+type gateContextKey struct{}
+
+// callGate counts the calls in flight so that Close can wait for them.
+type callGate struct {
+	mu     sync.Mutex
+	closed bool
+	calls  int
+	idle   chan struct{}
+}
+
+func newCallGate() *callGate {
+	return &callGate{idle: make(chan struct{})}
+}
+
+// enter registers a call, it fails with ErrClientClosed once close started
+// unless ctx comes from a call holding a slot, which close waits for anyway.
+func (g *callGate) enter(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed && ctx.Value(gateContextKey{}) != g {
+		return ErrClientClosed
+	}
+	g.calls++
+	return nil
+}
+
+// hold marks ctx as coming from a call holding a slot of g.
+func (g *callGate) hold(ctx context.Context) context.Context {
+	return context.WithValue(ctx, gateContextKey{}, g)
+}
+
+func (g *callGate) leave() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.calls--
+	if g.closed && g.calls == 0 {
+		close(g.idle)
+	}
+}
+
+// close refuses new calls and waits for the ones in flight until ctx is
+// done. It returns ErrClientClosed if it was already called.
+func (g *callGate) close(ctx context.Context) error {
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		return ErrClientClosed
+	}
+	g.closed = true
+	if g.calls == 0 {
+		close(g.idle)
+	}
+	g.mu.Unlock()
+	select {
+	case <-g.idle:
+		return nil
+	case <-ctx.Done():
+		return wrapError(ctx.Err())
+	}
+}
+
+// Close stops the client. New calls fail with ErrClientClosed while the
+// statements, transactions and cursors in flight are waited for until ctx
+// is done; the calls made with the context of a running transaction
+// callback still go through. The background checks, the pool metrics, the
+// cached statements, the pool and the replicas are then closed, aborting
+// what is still running; the error of ctx is returned in that case.
+func (mc *MysqlClient) Close(ctx context.Context) error {
+	err := mc.gate.close(ctx)
+	if err == ErrClientClosed {
+		return err
+	}
+	mc.stopBackground()
+	mc.unregisterMetrics()
+	if mc.stmts != nil {
+		mc.stmts.close()
+	}
+	if mc.kills != nil {
+		mc.kills.close()
+	}
+	closeErr := mc.GetDB().Close()
+	for _, pool := range mc.config.replicas {
+		if replicaErr := pool.Close(); closeErr == nil {
+			closeErr = replicaErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
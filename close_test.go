@@ -0,0 +1,80 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"github.com/sillyhatxu/db-client/metrics"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCallGate(t *testing.T) {
+	gate := newCallGate()
+	assert.Nil(t, gate.enter(context.Background()))
+	held := gate.hold(context.Background())
+	assert.Nil(t, gate.enter(held))
+
+	closed := make(chan error)
+	go func() {
+		closed <- gate.close(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, ErrClientClosed, gate.enter(context.Background()))
+	assert.Equal(t, ErrClientClosed, gate.enter(newCallGate().hold(context.Background())))
+	// a call made on behalf of one in flight still goes through
+	assert.Nil(t, gate.enter(held))
+	gate.leave()
+	gate.leave()
+	select {
+	case <-closed:
+		t.Fatal("close returned with a call in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+	gate.leave()
+	assert.Nil(t, <-closed)
+	assert.Equal(t, ErrClientClosed, gate.close(context.Background()))
+}
+
+func TestCallGateTimeout(t *testing.T) {
+	gate := newCallGate()
+	assert.Nil(t, gate.enter(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, TimeOutError, gate.close(ctx))
+	gate.leave()
+}
+
+func TestExecutorEnter(t *testing.T) {
+	gate := newCallGate()
+	e := &executor{config: &Config{}, gate: gate}
+	tx := &executor{config: &Config{}, gate: gate, inTx: true}
+	assert.Nil(t, gate.close(context.Background()))
+	_, err := e.enter(context.Background())
+	assert.Equal(t, ErrClientClosed, err)
+	leave, err := tx.enter(context.Background())
+	assert.Nil(t, err)
+	leave()
+}
+
+func TestMysqlClient_CloseUnregistersMetrics(t *testing.T) {
+	r := metrics.NewRegistry()
+	for i := 0; i < 2; i++ {
+		pool, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+		assert.Nil(t, err)
+		mc := &MysqlClient{config: &Config{pool: pool}, gate: newCallGate(), stopCh: make(chan struct{})}
+		mc.unregister = append(mc.unregister, metrics.RegisterDBStats(r, pool, "orders", primaryPoolName))
+		assert.Equal(t, 1, len(poolSeries(r)))
+		assert.Nil(t, mc.Close(context.Background()))
+		assert.Equal(t, 0, len(poolSeries(r)))
+	}
+}
+
+func poolSeries(r *metrics.Registry) []metrics.Series {
+	for _, metric := range r.Collect() {
+		if metric.Name == "db_client_pool_open_connections" {
+			return metric.Series
+		}
+	}
+	return nil
+}
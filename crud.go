@@ -0,0 +1,430 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/sillyhatxu/db-client/builder"
+	"github.com/sillyhatxu/db-client/structs"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	errNoPrimaryKey = errors.New("struct has no primary key field, tag one with column:\"name,pk\"")
+	errNotStruct    = errors.New("value must be a struct, a pointer to a struct or a slice of them")
+
+	crudModels sync.Map
+)
+
+type crudField struct {
+	column    string
+	index     []int
+	pk        bool
+	auto      bool
+	omitempty bool
+}
+
+// crudModel is how a struct type maps to a table for the struct CRUD
+// methods, it is cached per type.
+type crudModel struct {
+	fields []crudField
+	pks    []crudField
+	auto   *crudField
+}
+
+func getCrudModel(typ reflect.Type) (*crudModel, error) {
+	if model, ok := crudModels.Load(typ); ok {
+		return model.(*crudModel), nil
+	}
+	model := &crudModel{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get(structs.DefaultTagName)
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		options := strings.Split(tag, ",")
+		f := crudField{column: options[0], index: field.Index}
+		if f.column == "" {
+			f.column = field.Name
+		}
+		for _, option := range options[1:] {
+			switch option {
+			case "pk":
+				f.pk = true
+			case "auto":
+				f.auto = true
+			case "omitempty":
+				f.omitempty = true
+			}
+		}
+		if f.auto {
+			if model.auto != nil {
+				return nil, fmt.Errorf("'%s' has more than one auto field", typ)
+			}
+			if !isIntKind(field.Type.Kind()) {
+				return nil, fmt.Errorf("auto field '%s' of '%s' must be an integer", field.Name, typ)
+			}
+			auto := f
+			model.auto = &auto
+		}
+		model.fields = append(model.fields, f)
+		if f.pk {
+			model.pks = append(model.pks, f)
+		}
+	}
+	crudModels.Store(typ, model)
+	return model, nil
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// insertMap returns the columns inserted for v: omitempty fields are left
+// out when zero, and so is the auto field, the database assigns it.
+func (m *crudModel) insertMap(v reflect.Value) map[string]interface{} {
+	row := make(map[string]interface{}, len(m.fields))
+	for _, f := range m.fields {
+		value := v.FieldByIndex(f.index)
+		if (f.omitempty || f.auto) && isZero(value) {
+			continue
+		}
+		row[f.column] = value.Interface()
+	}
+	return row
+}
+
+// field returns the field of column.
+func (m *crudModel) field(column string) (crudField, bool) {
+	for _, f := range m.fields {
+		if f.column == column {
+			return f, true
+		}
+	}
+	//same case-insensitive fallback as the decoder
+	for _, f := range m.fields {
+		if strings.EqualFold(f.column, column) {
+			return f, true
+		}
+	}
+	return crudField{}, false
+}
+
+// updateMap returns the columns updated for v, every field but the primary
+// key and zero omitempty fields.
+func (m *crudModel) updateMap(v reflect.Value) map[string]interface{} {
+	row := make(map[string]interface{}, len(m.fields))
+	for _, f := range m.fields {
+		value := v.FieldByIndex(f.index)
+		if f.pk || (f.omitempty && isZero(value)) {
+			continue
+		}
+		row[f.column] = value.Interface()
+	}
+	return row
+}
+
+func (m *crudModel) pkWhere(v reflect.Value) (map[string]interface{}, error) {
+	if len(m.pks) == 0 {
+		return nil, errNoPrimaryKey
+	}
+	where := make(map[string]interface{}, len(m.pks))
+	for _, f := range m.pks {
+		where[f.column] = v.FieldByIndex(f.index).Interface()
+	}
+	return where, nil
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// structValues returns the structs held by v, a struct, a pointer to one or
+// a slice of either. Only the structs reached through a pointer or a slice
+// are addressable.
+func structValues(v interface{}) (reflect.Type, []reflect.Value, error) {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() || value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil, nil, errNotStruct
+	}
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if isStructTarget(value.Type()) {
+		return value.Type(), []reflect.Value{value}, nil
+	}
+	if value.Kind() != reflect.Slice {
+		return nil, nil, errNotStruct
+	}
+	elemType := value.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if !isStructTarget(elemType) {
+		return nil, nil, errNotStruct
+	}
+	values := make([]reflect.Value, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		elem := value.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				return nil, nil, fmt.Errorf("nil element %d in %s", i, value.Type())
+			}
+			elem = elem.Elem()
+		}
+		values = append(values, elem)
+	}
+	return elemType, values, nil
+}
+
+func (mc *MysqlClient) InsertStruct(table string, v interface{}) (int64, error) {
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.InsertStructContext(ctx, table, v)
+}
+
+// InsertStructContext inserts v, a struct, a pointer to a struct or a slice
+// of either, and returns the number of rows inserted. Fields are mapped to
+// columns with the column tag; "-" skips a field, omitempty leaves it out
+// when zero and auto marks the auto-increment id, which is left out when
+// zero and then set back into the structs that are addressable. A slice
+// is inserted in a transaction with as few statements as InsertBatch would
+// use, which relies on the ids of a multi-row INSERT being consecutive
+// (auto_increment_increment=1).
+func (mc *MysqlClient) InsertStructContext(ctx context.Context, table string, v interface{}) (int64, error) {
+	typ, values, err := structValues(v)
+	if err != nil {
+		return 0, err
+	}
+	model, err := getCrudModel(typ)
+	if err != nil {
+		return 0, err
+	}
+	if len(values) == 0 {
+		return 0, nil
+	}
+	var rowsAffected int64
+	err = mc.WithTxContext(ctx, func(ctx context.Context, tx *Tx) error {
+		rowsAffected = 0
+		for _, group := range groupInsertRows(model, values) {
+			if len(group.rows[0]) == 0 {
+				result, err := insertDefaultRows(ctx, tx, table, len(group.rows))
+				if err != nil {
+					return err
+				}
+				rowsAffected += result.RowsAffected
+				if group.setAuto {
+					setAutoIds(model, group.values, result.FirstIds[0])
+				}
+				continue
+			}
+			for _, chunk := range chunkBatch(table, group.rows, BatchOptions{}) {
+				result, err := insertChunks(ctx, tx, table, [][]map[string]interface{}{chunk})
+				if err != nil {
+					return err
+				}
+				rowsAffected += result.RowsAffected
+				if group.setAuto {
+					setAutoIds(model, group.values[:len(chunk)], result.FirstIds[0])
+				}
+				group.values = group.values[len(chunk):]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rowsAffected, nil
+}
+
+type insertGroup struct {
+	rows    []map[string]interface{}
+	values  []reflect.Value
+	setAuto bool
+}
+
+// groupInsertRows splits values into runs of rows inserting the same
+// columns, omitempty and auto fields may differ from one row to the other.
+func groupInsertRows(model *crudModel, values []reflect.Value) []*insertGroup {
+	var groups []*insertGroup
+	var current *insertGroup
+	var signature string
+	for _, value := range values {
+		row := model.insertMap(value)
+		var sb strings.Builder
+		for _, f := range model.fields {
+			if _, ok := row[f.column]; ok {
+				sb.WriteString(f.column)
+				sb.WriteByte(',')
+			}
+		}
+		if current == nil || sb.String() != signature {
+			current = &insertGroup{}
+			if model.auto != nil {
+				_, inserted := row[model.auto.column]
+				current.setAuto = !inserted
+			}
+			signature = sb.String()
+			groups = append(groups, current)
+		}
+		current.rows = append(current.rows, row)
+		current.values = append(current.values, value)
+	}
+	return groups
+}
+
+// insertDefaultRows inserts n rows made of the column defaults, for structs
+// whose fields are all auto or zero omitempty.
+func insertDefaultRows(ctx context.Context, session Session, table string, n int) (BatchResult, error) {
+	query, args, err := builder.BuildInsertDefaults(table, n)
+	if err != nil {
+		return BatchResult{}, err
+	}
+	res, err := session.ExecContext(ctx, query, args...)
+	if err != nil {
+		return BatchResult{}, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return BatchResult{}, err
+	}
+	firstId, err := res.LastInsertId()
+	if err != nil {
+		return BatchResult{}, err
+	}
+	return BatchResult{RowsAffected: rowsAffected, FirstIds: []int64{firstId}}, nil
+}
+
+func setAutoIds(model *crudModel, values []reflect.Value, firstId int64) {
+	for i, value := range values {
+		if !value.CanSet() {
+			continue
+		}
+		field := value.FieldByIndex(model.auto.index)
+		switch field.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			field.SetUint(uint64(firstId + int64(i)))
+		default:
+			field.SetInt(firstId + int64(i))
+		}
+	}
+}
+
+func (mc *MysqlClient) UpdateStruct(table string, v interface{}) (int64, error) {
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.UpdateStructContext(ctx, table, v)
+}
+
+// UpdateStructContext updates the row of v, a struct or a pointer to one,
+// found by its pk fields. Every other field is written except the zero
+// omitempty ones.
+func (mc *MysqlClient) UpdateStructContext(ctx context.Context, table string, v interface{}) (int64, error) {
+	model, value, err := singleStruct(v)
+	if err != nil {
+		return 0, err
+	}
+	where, err := model.pkWhere(value)
+	if err != nil {
+		return 0, err
+	}
+	sql, args, err := builder.BuildUpdate(table, where, model.updateMap(value))
+	if err != nil {
+		return 0, err
+	}
+	return mc.UpdateContext(ctx, sql, args...)
+}
+
+func (mc *MysqlClient) DeleteStruct(table string, v interface{}) (int64, error) {
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.DeleteStructContext(ctx, table, v)
+}
+
+// DeleteStructContext deletes the row of v, a struct or a pointer to one,
+// found by its pk fields.
+func (mc *MysqlClient) DeleteStructContext(ctx context.Context, table string, v interface{}) (int64, error) {
+	model, value, err := singleStruct(v)
+	if err != nil {
+		return 0, err
+	}
+	where, err := model.pkWhere(value)
+	if err != nil {
+		return 0, err
+	}
+	sql, args, err := builder.BuildDelete(table, where)
+	if err != nil {
+		return 0, err
+	}
+	return mc.DeleteContext(ctx, sql, args...)
+}
+
+func (mc *MysqlClient) FindByPK(table string, output interface{}, pk ...interface{}) error {
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.FindByPKContext(ctx, table, output, pk...)
+}
+
+// FindByPKContext reads the row whose primary key is pk into output, a
+// pointer to a struct. pk holds a value per pk field in field order. It
+// returns sql.ErrNoRows when there is no such row.
+func (mc *MysqlClient) FindByPKContext(ctx context.Context, table string, output interface{}, pk ...interface{}) error {
+	outVal, ok := structTarget(output)
+	if !ok {
+		return errNotStruct
+	}
+	model, err := getCrudModel(outVal.Type())
+	if err != nil {
+		return err
+	}
+	if len(model.pks) == 0 {
+		return errNoPrimaryKey
+	}
+	if len(pk) != len(model.pks) {
+		return fmt.Errorf("'%s' has %d pk fields, got %d values", outVal.Type(), len(model.pks), len(pk))
+	}
+	where := make(map[string]interface{}, len(pk))
+	for i, f := range model.pks {
+		where[f.column] = pk[i]
+	}
+	query, args, err := builder.BuildSelect(table, where, nil)
+	if err != nil {
+		return err
+	}
+	found, err := mc.readExecutor(ctx).findFirst(ctx, query, output, args...)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func singleStruct(v interface{}) (*crudModel, reflect.Value, error) {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() || value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil, reflect.Value{}, errNotStruct
+	}
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if !isStructTarget(value.Type()) {
+		return nil, reflect.Value{}, errNotStruct
+	}
+	model, err := getCrudModel(value.Type())
+	if err != nil {
+		return nil, reflect.Value{}, err
+	}
+	return model, value, nil
+}
@@ -0,0 +1,175 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type crudUser struct {
+	Id        int64  `column:"id,pk,auto"`
+	LoginName string `column:"login_name"`
+	Age       int    `column:"age,omitempty"`
+	Secret    string `column:"-"`
+	Platform  string
+	internal  string
+}
+
+func TestGetCrudModel(t *testing.T) {
+	model, err := getCrudModel(reflect.TypeOf(crudUser{}))
+	assert.Nil(t, err)
+	var columns []string
+	for _, f := range model.fields {
+		columns = append(columns, f.column)
+	}
+	assert.EqualValues(t, []string{"id", "login_name", "age", "Platform"}, columns)
+	assert.Equal(t, 1, len(model.pks))
+	assert.Equal(t, "id", model.pks[0].column)
+	assert.Equal(t, "id", model.auto.column)
+
+	type twoAuto struct {
+		A int64 `column:"a,auto"`
+		B int64 `column:"b,auto"`
+	}
+	_, err = getCrudModel(reflect.TypeOf(twoAuto{}))
+	assert.NotNil(t, err)
+	type stringAuto struct {
+		A string `column:"a,pk,auto"`
+	}
+	_, err = getCrudModel(reflect.TypeOf(stringAuto{}))
+	assert.NotNil(t, err)
+}
+
+func TestCrudModel_Maps(t *testing.T) {
+	model, err := getCrudModel(reflect.TypeOf(crudUser{}))
+	assert.Nil(t, err)
+	user := reflect.ValueOf(crudUser{LoginName: "a", Secret: "s", Platform: "ios"})
+	assert.EqualValues(t, map[string]interface{}{"login_name": "a", "Platform": "ios"}, model.insertMap(user))
+	assert.EqualValues(t, map[string]interface{}{"login_name": "a", "Platform": "ios"}, model.updateMap(user))
+
+	user = reflect.ValueOf(crudUser{Id: 7, LoginName: "a", Age: 30})
+	assert.EqualValues(t, map[string]interface{}{"id": int64(7), "login_name": "a", "age": 30, "Platform": ""}, model.insertMap(user))
+	assert.EqualValues(t, map[string]interface{}{"login_name": "a", "age": 30, "Platform": ""}, model.updateMap(user))
+	where, err := model.pkWhere(user)
+	assert.Nil(t, err)
+	assert.EqualValues(t, map[string]interface{}{"id": int64(7)}, where)
+
+	type noPK struct {
+		Name string `column:"name"`
+	}
+	model, err = getCrudModel(reflect.TypeOf(noPK{}))
+	assert.Nil(t, err)
+	_, err = model.pkWhere(reflect.ValueOf(noPK{}))
+	assert.Equal(t, errNoPrimaryKey, err)
+}
+
+func TestGroupInsertRows(t *testing.T) {
+	users := []crudUser{
+		{LoginName: "a"},
+		{LoginName: "b"},
+		{LoginName: "c", Age: 20},
+		{Id: 10, LoginName: "d", Age: 20},
+		{LoginName: "e"},
+	}
+	typ, values, err := structValues(users)
+	assert.Nil(t, err)
+	model, err := getCrudModel(typ)
+	assert.Nil(t, err)
+	groups := groupInsertRows(model, values)
+	var sizes []int
+	var setAuto []bool
+	for _, group := range groups {
+		sizes = append(sizes, len(group.rows))
+		setAuto = append(setAuto, group.setAuto)
+	}
+	assert.EqualValues(t, []int{2, 1, 1, 1}, sizes)
+	assert.EqualValues(t, []bool{true, true, false, true}, setAuto)
+
+	setAutoIds(model, groups[0].values, 100)
+	assert.EqualValues(t, 100, users[0].Id)
+	assert.EqualValues(t, 101, users[1].Id)
+}
+
+func TestStructValues(t *testing.T) {
+	user := crudUser{}
+	_, values, err := structValues(user)
+	assert.Nil(t, err)
+	assert.False(t, values[0].CanSet())
+	_, values, err = structValues(&user)
+	assert.Nil(t, err)
+	assert.True(t, values[0].CanSet())
+	_, values, err = structValues([]*crudUser{&user, &user})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(values))
+	_, _, err = structValues([]*crudUser{nil})
+	assert.NotNil(t, err)
+	_, _, err = structValues(1)
+	assert.Equal(t, errNotStruct, err)
+	_, _, err = structValues([]int{1})
+	assert.Equal(t, errNotStruct, err)
+}
+
+type defaultsOnly struct {
+	Id   int64  `column:"id,pk,auto"`
+	Name string `column:"name,omitempty"`
+}
+
+type execResult struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (r execResult) LastInsertId() (int64, error) { return r.lastInsertId, nil }
+func (r execResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// execSession records the statements of ExecContext, the other methods of
+// Session are not implemented.
+type execSession struct {
+	Session
+	queries []string
+}
+
+func (s *execSession) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	s.queries = append(s.queries, query)
+	return execResult{lastInsertId: 7, rowsAffected: 2}, nil
+}
+
+func TestInsertDefaultRows(t *testing.T) {
+	rows := []defaultsOnly{{}, {}}
+	typ, values, err := structValues(rows)
+	assert.Nil(t, err)
+	model, err := getCrudModel(typ)
+	assert.Nil(t, err)
+	groups := groupInsertRows(model, values)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, 0, len(groups[0].rows[0]))
+	assert.True(t, groups[0].setAuto)
+
+	session := &execSession{}
+	result, err := insertDefaultRows(context.Background(), session, "t", len(groups[0].rows))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"INSERT INTO t () VALUES (),()"}, session.queries)
+	assert.EqualValues(t, BatchResult{RowsAffected: 2, FirstIds: []int64{7}}, result)
+	setAutoIds(model, groups[0].values, result.FirstIds[0])
+	assert.EqualValues(t, 7, rows[0].Id)
+	assert.EqualValues(t, 8, rows[1].Id)
+}
+
+func TestNilStruct(t *testing.T) {
+	var user *crudUser
+	for _, v := range []interface{}{nil, user} {
+		_, _, err := structValues(v)
+		assert.Equal(t, errNotStruct, err)
+		_, _, err = singleStruct(v)
+		assert.Equal(t, errNotStruct, err)
+	}
+	mc := &MysqlClient{}
+	_, err := mc.InsertStructContext(context.Background(), "user", nil)
+	assert.Equal(t, errNotStruct, err)
+	_, err = mc.UpdateStructContext(context.Background(), "user", user)
+	assert.Equal(t, errNotStruct, err)
+	_, err = mc.DeleteStructContext(context.Background(), "user", nil)
+	assert.Equal(t, errNotStruct, err)
+}
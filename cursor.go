@@ -0,0 +1,122 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/sillyhatxu/db-client/decoder"
+	"reflect"
+)
+
+// ErrStopIteration can be returned by a RowFunc to stop FindEach early
+// without reporting an error.
+var ErrStopIteration = errors.New("stop iteration")
+
+// Cursor streams the rows of a query one at a time instead of loading the
+// whole result set into memory. It must be closed after use.
+type Cursor struct {
+	rows    *sql.Rows
+	typed   bool
+	scanner *mapScanner
+	config  *decoder.Config
+	release func()
+	unpin   func(error) error
+	leave   func()
+}
+
+type RowFunc func(cursor *Cursor) error
+
+// QueryCursor runs the query and returns a Cursor positioned before the
+// first row. The cursor is bound to ctx for its whole lifetime.
+func (mc *MysqlClient) QueryCursor(ctx context.Context, sql string, args ...interface{}) (*Cursor, error) {
+	return mc.readExecutor(ctx).queryCursor(ctx, sql, args...)
+}
+
+func newCursor(rows *sql.Rows, typed bool) *Cursor {
+	return &Cursor{
+		rows:   rows,
+		typed:  typed,
+		config: decoder.DefaultConfig(),
+	}
+}
+
+func (mc *MysqlClient) FindEach(sql string, rowFunc RowFunc, args ...interface{}) error {
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.FindEachContext(ctx, sql, rowFunc, args...)
+}
+
+// FindEachContext calls rowFunc for every row of the query. Iteration stops
+// at the first error returned by rowFunc; ErrStopIteration stops it without
+// error.
+func (mc *MysqlClient) FindEachContext(ctx context.Context, sql string, rowFunc RowFunc, args ...interface{}) error {
+	return mc.readExecutor(ctx).findEach(ctx, sql, rowFunc, args...)
+}
+
+func (c *Cursor) Next() bool {
+	return c.rows.Next()
+}
+
+func (c *Cursor) Columns() ([]string, error) {
+	return c.rows.Columns()
+}
+
+// Scan copies the columns of the current row into dest, see sql.Rows.Scan.
+func (c *Cursor) Scan(dest ...interface{}) error {
+	return c.rows.Scan(dest...)
+}
+
+// Map returns the current row keyed by column name, following the result
+// mode of the client.
+func (c *Cursor) Map() (map[string]interface{}, error) {
+	if c.scanner == nil {
+		scanner, err := newMapScanner(c.rows, c.typed)
+		if err != nil {
+			return nil, err
+		}
+		c.scanner = scanner
+	}
+	return c.scanner.scan(c.rows)
+}
+
+// Decode decodes the current row into output, which must be a pointer. The
+// output is zeroed first so it can be reused across rows.
+func (c *Cursor) Decode(output interface{}) error {
+	outVal := reflect.ValueOf(output)
+	if outVal.Kind() != reflect.Ptr || outVal.IsNil() {
+		return fmt.Errorf("output must be a non-nil pointer, got %T", output)
+	}
+	row, err := c.Map()
+	if err != nil {
+		return err
+	}
+	outVal = outVal.Elem()
+	outVal.Set(reflect.Zero(outVal.Type()))
+	return c.config.Decode(row, output)
+}
+
+func (c *Cursor) Err() error {
+	return wrapError(c.rows.Err())
+}
+
+// Close releases the cursor. With KillOnTimeout it returns the *KillError
+// of a query killed because the context of the cursor was done.
+func (c *Cursor) Close() error {
+	err := c.rows.Close()
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+	if c.unpin != nil {
+		if killErr := c.unpin(c.rows.Err()); isKillError(killErr) {
+			err = killErr
+		}
+		c.unpin = nil
+	}
+	if c.leave != nil {
+		c.leave()
+		c.leave = nil
+	}
+	return err
+}
@@ -63,3 +63,42 @@ func TestMysqlClient_Find(t *testing.T) {
 
 	assert.EqualValues(t, 100, len(userArray))
 }
+
+func TestMysqlClient_FindEach(t *testing.T) {
+	once.Do(setup)
+	var userArray []User
+	err := mysqlClient.FindEach("select * from user", func(cursor *Cursor) error {
+		var user User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		userArray = append(userArray, user)
+		if len(userArray) == 10 {
+			return ErrStopIteration
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 10, len(userArray))
+}
+
+func TestCursor_DecodeOutput(t *testing.T) {
+	cursor := &Cursor{}
+	var user User
+	var nilUser *User
+	for _, output := range []interface{}{nil, user, nilUser} {
+		assert.NotNil(t, cursor.Decode(output), "%T", output)
+	}
+}
+
+func TestMysqlClient_StmtCacheStats(t *testing.T) {
+	once.Do(setup)
+	before := mysqlClient.StmtCacheStats()
+	for i := 0; i < 3; i++ {
+		_, err := mysqlClient.Count("select count(1) from user where status = ?", true)
+		assert.Nil(t, err)
+	}
+	after := mysqlClient.StmtCacheStats()
+	assert.EqualValues(t, before.Misses+1, after.Misses)
+	assert.EqualValues(t, before.Hits+2, after.Hits)
+}
@@ -0,0 +1,134 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrDuplicateKey    = errors.New("duplicate key")
+	ErrForeignKey      = errors.New("foreign key constraint violation")
+	ErrTableNotExist   = errors.New("table doesn't exist")
+	ErrDeadlock        = errors.New("deadlock")
+	ErrLockWaitTimeout = errors.New("lock wait timeout")
+	ErrReadOnly        = errors.New("server is read only")
+	ErrConnectionLost  = errors.New("connection lost")
+)
+
+const (
+	errNumberServerShutdown      = 1053
+	errNumberDuplicateEntry      = 1062
+	errNumberNoSuchTable         = 1146
+	errNumberLockWaitTimeout     = 1205
+	errNumberDeadlock            = 1213
+	errNumberReadOnly            = 1290
+	errNumberRowIsReferenced     = 1451
+	errNumberNoReferencedRow     = 1452
+	errNumberReadOnlyTransaction = 1792
+	errNumberServerGone          = 2006
+	errNumberServerLost          = 2013
+)
+
+var errNumberKinds = map[uint16]error{
+	errNumberServerShutdown:      ErrConnectionLost,
+	errNumberDuplicateEntry:      ErrDuplicateKey,
+	errNumberNoSuchTable:         ErrTableNotExist,
+	errNumberReadOnly:            ErrReadOnly,
+	errNumberRowIsReferenced:     ErrForeignKey,
+	errNumberNoReferencedRow:     ErrForeignKey,
+	errNumberReadOnlyTransaction: ErrReadOnly,
+	errNumberServerGone:          ErrConnectionLost,
+	errNumberServerLost:          ErrConnectionLost,
+	errNumberDeadlock:            ErrDeadlock,
+	errNumberLockWaitTimeout:     ErrLockWaitTimeout,
+}
+
+// Duplicate entry 'sillyhat' for key 'login_name', MySQL 8 prefixes the key with the table name
+var duplicateEntryRegexp = regexp.MustCompile(`^Duplicate entry '(.*)' for key '(.*)'$`)
+
+// Error is a classified database error. Kind is one of the Err sentinels
+// and errors.Is(err, Kind) holds. Error() keeps the original message.
+type Error struct {
+	Kind    error
+	Number  uint16
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// DuplicateKeyError is returned for error 1062 and matches ErrDuplicateKey.
+type DuplicateKeyError struct {
+	Number  uint16
+	Message string
+	// Entry is the duplicated value and Index the name of the unique index.
+	Entry string
+	Index string
+	Err   error
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DuplicateKeyError) Is(target error) bool {
+	return target == ErrDuplicateKey
+}
+
+func (e *DuplicateKeyError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError maps a *mysql.MySQLError or a lost connection to an *Error
+// or a *DuplicateKeyError. Any other error is returned unchanged.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch err.(type) {
+	case *Error, *DuplicateKeyError:
+		return err
+	}
+	if errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, driver.ErrBadConn) {
+		return &Error{Kind: ErrConnectionLost, Message: err.Error(), Err: err}
+	}
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+	kind, ok := errNumberKinds[mysqlErr.Number]
+	if !ok {
+		return err
+	}
+	if kind == ErrDuplicateKey {
+		dupErr := &DuplicateKeyError{Number: mysqlErr.Number, Message: mysqlErr.Message, Err: err}
+		if match := duplicateEntryRegexp.FindStringSubmatch(mysqlErr.Message); match != nil {
+			dupErr.Entry = match[1]
+			dupErr.Index = match[2][strings.LastIndex(match[2], ".")+1:]
+		}
+		return dupErr
+	}
+	return &Error{Kind: kind, Number: mysqlErr.Number, Message: mysqlErr.Message, Err: err}
+}
+
+// wrapError maps context deadlines to TimeOutError and classifies the rest.
+func wrapError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return TimeOutError
+	}
+	return ClassifyError(err)
+}
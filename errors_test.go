@@ -0,0 +1,50 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	var data = []struct {
+		err  error
+		kind error
+	}{
+		{err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a' for key 'login_name'"}, kind: ErrDuplicateKey},
+		{err: &mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row"}, kind: ErrForeignKey},
+		{err: &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"}, kind: ErrForeignKey},
+		{err: &mysql.MySQLError{Number: 1146, Message: "Table 'db.test' doesn't exist"}, kind: ErrTableNotExist},
+		{err: &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}, kind: ErrDeadlock},
+		{err: &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, kind: ErrLockWaitTimeout},
+		{err: &mysql.MySQLError{Number: 1290, Message: "running with the --read-only option"}, kind: ErrReadOnly},
+		{err: &mysql.MySQLError{Number: 1792, Message: "Cannot execute statement in a READ ONLY transaction"}, kind: ErrReadOnly},
+		{err: mysql.ErrInvalidConn, kind: ErrConnectionLost},
+		{err: driver.ErrBadConn, kind: ErrConnectionLost},
+	}
+	for _, d := range data {
+		err := ClassifyError(fmt.Errorf("wrapped: %w", d.err))
+		assert.True(t, errors.Is(err, d.kind), "%v", d.err)
+		assert.True(t, errors.Is(err, d.err))
+		assert.EqualValues(t, "wrapped: "+d.err.Error(), err.Error())
+	}
+	other := errors.New("other")
+	assert.Equal(t, other, ClassifyError(other))
+	assert.Equal(t, TimeOutError, wrapError(context.DeadlineExceeded))
+	assert.Nil(t, ClassifyError(nil))
+}
+
+func TestDuplicateKeyError(t *testing.T) {
+	err := ClassifyError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'it's' for key 'user.login_name'"})
+	var dupErr *DuplicateKeyError
+	assert.True(t, errors.As(err, &dupErr))
+	assert.EqualValues(t, "it's", dupErr.Entry)
+	assert.EqualValues(t, "login_name", dupErr.Index)
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+	assert.False(t, errors.Is(err, ErrForeignKey))
+	assert.True(t, isRetryable(ClassifyError(&mysql.MySQLError{Number: 1213})))
+}
@@ -0,0 +1,334 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"github.com/sillyhatxu/db-client/decoder"
+)
+
+// queryer is implemented by *sql.DB, *sql.Tx and *sql.Conn.
+type queryer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// executor holds the statement logic shared by MysqlClient and Tx. Every
+// statement goes through the interceptor chain of the config, see run.
+type executor struct {
+	config *Config
+	q      queryer
+	inTx   bool
+	// stmts caches the statements of db, the pool behind q; nil disables
+	// the cache.
+	stmts *stmtCache
+	db    *sql.DB
+	// gate counts the calls outside transactions for Close, nil for none.
+	gate *callGate
+	// kills holds the connections reserved for KillOnTimeout, nil when off.
+	kills *killConns
+}
+
+// enter registers a call outside transactions with the gate, it returns the
+// function to call once the call is done.
+func (e *executor) enter(ctx context.Context) (func(), error) {
+	if e.gate == nil || e.inTx {
+		return func() {}, nil
+	}
+	if err := e.gate.enter(ctx); err != nil {
+		return nil, err
+	}
+	return e.gate.leave, nil
+}
+
+// run runs fn through the interceptor chain with the executor it must use.
+func (e *executor) run(ctx context.Context, call *Call, fn func(ctx context.Context, e *executor, call *Call) error) error {
+	leave, err := e.enter(ctx)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	return e.config.intercept(ctx, call, func(ctx context.Context, call *Call) error {
+		pinned, unpin, err := e.pin(ctx)
+		if err != nil {
+			return err
+		}
+		return unpin(fn(ctx, pinned, call))
+	})
+}
+
+func (e *executor) newCall(op Operation, sql string, args []interface{}) *Call {
+	return &Call{Op: op, SQL: sql, Args: args, InTx: e.inTx, Rows: -1}
+}
+
+// prepare returns the statement of query and the function to call once it
+// is no longer used.
+func (e *executor) prepare(ctx context.Context, query string) (*sql.Stmt, func(), error) {
+	if e.stmts == nil {
+		stmt, err := e.q.PrepareContext(ctx, query)
+		if err != nil {
+			return nil, nil, wrapError(err)
+		}
+		return stmt, func() { _ = stmt.Close() }, nil
+	}
+	if tx, ok := e.q.(*sql.Tx); ok {
+		// preparing on the pool would need a second connection while the
+		// transaction holds one, so only a cached statement is reused
+		entry, ok := e.stmts.lookup(e.db, query)
+		if !ok {
+			stmt, err := tx.PrepareContext(ctx, query)
+			if err != nil {
+				return nil, nil, wrapError(err)
+			}
+			return stmt, func() { _ = stmt.Close() }, nil
+		}
+		stmt := tx.StmtContext(ctx, entry.stmt)
+		return stmt, func() {
+			_ = stmt.Close()
+			e.stmts.release(entry)
+		}, nil
+	}
+	entry, err := e.stmts.get(ctx, e.db, query)
+	if err != nil {
+		return nil, nil, wrapError(err)
+	}
+	return entry.stmt, func() { e.stmts.release(entry) }, nil
+}
+
+// query runs a query of the call. Without the statement cache it skips the
+// explicit prepare, as database/sql does it on the connection anyway.
+func (e *executor) query(ctx context.Context, call *Call) (*sql.Rows, func(), error) {
+	if e.stmts == nil {
+		rows, err := e.q.QueryContext(ctx, call.SQL, call.Args...)
+		return rows, func() {}, wrapError(err)
+	}
+	stmt, release, err := e.prepare(ctx, call.SQL)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, call.Args...)
+	if err != nil {
+		release()
+		return nil, nil, wrapError(err)
+	}
+	return rows, release, nil
+}
+
+func (e *executor) exec(ctx context.Context, sql string, args ...interface{}) (sql.Result, error) {
+	call := e.newCall(OpExec, sql, args)
+	err := e.run(ctx, call, func(ctx context.Context, e *executor, call *Call) error {
+		stm, release, err := e.prepare(ctx, call.SQL)
+		if err != nil {
+			return err
+		}
+		defer release()
+		result, err := stm.ExecContext(ctx, call.Args...)
+		if err != nil {
+			return wrapError(err)
+		}
+		call.Result = result
+		if rowsAffected, err := result.RowsAffected(); err == nil {
+			call.Rows = rowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return call.Result, nil
+}
+
+func (e *executor) insert(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	result, err := e.exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (e *executor) rowsAffected(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	result, err := e.exec(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (e *executor) count(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	var count int64
+	call := e.newCall(OpCount, query, args)
+	err := e.run(ctx, call, func(ctx context.Context, e *executor, call *Call) error {
+		rows, release, err := e.query(ctx, call)
+		if err != nil {
+			return err
+		}
+		defer release()
+		defer rows.Close()
+		if !rows.Next() {
+			if err := rows.Err(); err != nil {
+				return wrapError(err)
+			}
+			return wrapError(sql.ErrNoRows)
+		}
+		if err := rows.Scan(&count); err != nil {
+			return wrapError(err)
+		}
+		call.Rows = 1
+		return wrapError(rows.Close())
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (e *executor) findCustom(ctx context.Context, query string, fieldFunc FieldFunc, args ...interface{}) error {
+	call := e.newCall(OpQuery, query, args)
+	return e.run(ctx, call, func(ctx context.Context, e *executor, call *Call) error {
+		rows, release, err := e.query(ctx, call)
+		if err != nil {
+			return err
+		}
+		defer release()
+		defer rows.Close()
+		call.Rows = 0
+		for rows.Next() {
+			call.Rows++
+			err := fieldFunc(rows)
+			if err != nil {
+				return err
+			}
+		}
+		return wrapError(rows.Err())
+	})
+}
+
+func (e *executor) find(ctx context.Context, sql string, output interface{}, args ...interface{}) error {
+	if outVal, elemType, ok := structSliceTarget(output); ok && e.config.directScan {
+		call := e.newCall(OpQuery, sql, args)
+		return e.run(ctx, call, func(ctx context.Context, e *executor, call *Call) error {
+			rows, release, err := e.query(ctx, call)
+			if err != nil {
+				return err
+			}
+			defer release()
+			defer rows.Close()
+			call.Rows, err = scanStructs(rows, outVal, elemType)
+			return err
+		})
+	}
+	result, err := e.findMapArray(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	return decoder.DefaultConfig().Decode(result, output)
+}
+
+// findFirst reads the first row into output and reports whether there was
+// one; output is left untouched otherwise.
+func (e *executor) findFirst(ctx context.Context, sql string, output interface{}, args ...interface{}) (bool, error) {
+	if outVal, ok := structTarget(output); ok && e.config.directScan {
+		call := e.newCall(OpQuery, sql, args)
+		err := e.run(ctx, call, func(ctx context.Context, e *executor, call *Call) error {
+			rows, release, err := e.query(ctx, call)
+			if err != nil {
+				return err
+			}
+			defer release()
+			defer rows.Close()
+			call.Rows, err = scanStruct(rows, outVal)
+			return err
+		})
+		return call.Rows > 0 && err == nil, err
+	}
+	array, err := e.findMapArray(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+	if array == nil || len(array) == 0 {
+		return false, nil
+	}
+	return true, decoder.DefaultConfig().Decode(array[0], output)
+}
+
+func (e *executor) findMapArray(ctx context.Context, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	var results []map[string]interface{}
+	call := e.newCall(OpQuery, sql, args)
+	err := e.run(ctx, call, func(ctx context.Context, e *executor, call *Call) error {
+		rows, release, err := e.query(ctx, call)
+		if err != nil {
+			return err
+		}
+		defer release()
+		cursor := newCursor(rows, e.config.typedResult)
+		defer cursor.Close()
+		for cursor.Next() {
+			row, err := cursor.Map()
+			if err != nil {
+				return err
+			}
+			results = append(results, row)
+		}
+		call.Rows = int64(len(results))
+		return cursor.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (e *executor) queryCursor(ctx context.Context, sql string, args ...interface{}) (*Cursor, error) {
+	var cursor *Cursor
+	leave, err := e.enter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	call := e.newCall(OpQuery, sql, args)
+	err = e.config.intercept(ctx, call, func(ctx context.Context, call *Call) error {
+		pinned, unpin, err := e.pin(ctx)
+		if err != nil {
+			return err
+		}
+		rows, release, err := pinned.query(ctx, call)
+		if err != nil {
+			return unpin(err)
+		}
+		cursor = newCursor(rows, e.config.typedResult)
+		cursor.release = release
+		cursor.unpin = unpin
+		cursor.leave = leave
+		return nil
+	})
+	if err != nil {
+		leave()
+		return nil, err
+	}
+	return cursor, nil
+}
+
+func (e *executor) findEach(ctx context.Context, sql string, rowFunc RowFunc, args ...interface{}) error {
+	call := e.newCall(OpQuery, sql, args)
+	return e.run(ctx, call, func(ctx context.Context, e *executor, call *Call) error {
+		rows, release, err := e.query(ctx, call)
+		if err != nil {
+			return err
+		}
+		defer release()
+		cursor := newCursor(rows, e.config.typedResult)
+		defer cursor.Close()
+		call.Rows = 0
+		for cursor.Next() {
+			call.Rows++
+			err := rowFunc(cursor)
+			if err == ErrStopIteration {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return cursor.Err()
+	})
+}
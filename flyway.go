@@ -1,7 +1,9 @@
 package mysqlclient
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"io/ioutil"
@@ -47,23 +49,47 @@ func (mc *MysqlClient) initialFlayway() (err error) {
 	if !mc.config.flyway {
 		return nil
 	}
-	err = mc.initialSchemaVersion()
+	ctx, span := mc.config.tracer.Start(context.Background(), migrationSpanName)
+	defer span.End()
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+		}
+	}()
+	err = mc.initialSchemaVersion(ctx)
 	if err != nil {
 		return err
 	}
-	err = mc.executeFlayway()
+	err = mc.executeFlayway(ctx)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// ExecDDL runs without the client timeout; schema changes on large tables
+// routinely outlive it. Use ExecDDLContext to bound it.
 func (mc *MysqlClient) ExecDDL(ddl string) error {
-	startT := time.Now()
-	result, err := mc.GetDB().Exec(ddl)
+	return mc.ExecDDLContext(context.Background(), ddl)
+}
+
+func (mc *MysqlClient) ExecDDLContext(ctx context.Context, ddl string) error {
+	call := &Call{Op: OpDDL, SQL: ddl, Rows: -1}
+	err := mc.config.intercept(ctx, call, func(ctx context.Context, call *Call) error {
+		result, err := mc.GetDB().ExecContext(ctx, call.SQL, call.Args...)
+		if err != nil {
+			return wrapError(err)
+		}
+		call.Result = result
+		if rowsAffected, err := result.RowsAffected(); err == nil {
+			call.Rows = rowsAffected
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
+	result := call.Result
 	lastInsertId, err := result.LastInsertId()
 	if err != nil {
 		return err
@@ -72,17 +98,18 @@ func (mc *MysqlClient) ExecDDL(ddl string) error {
 	if err != nil {
 		return err
 	}
-	tc := time.Since(startT)
-	log.Println("lastInsertId:", lastInsertId, "; rowsAffected : ", rowsAffected, " (execution: ", tc, ")")
+	log.Println("lastInsertId:", lastInsertId, "; rowsAffected : ", rowsAffected, " (execution: ", call.Duration, ")")
 	return nil
 }
 
-func (mc *MysqlClient) executeFlayway() error {
+func (mc *MysqlClient) executeFlayway(ctx context.Context) error {
 	files, err := ioutil.ReadDir(mc.config.ddlPath)
 	if err != nil {
 		return nil
 	}
-	svArray, err := mc.SchemaVersionArray()
+	timeoutCtx, cancel := context.WithTimeout(ctx, mc.config.timeout)
+	svArray, err := mc.SchemaVersionArrayContext(timeoutCtx)
+	cancel()
 	if err != nil {
 		return err
 	}
@@ -91,7 +118,7 @@ func (mc *MysqlClient) executeFlayway() error {
 		return err
 	}
 	for _, f := range files {
-		err := mc.readFile(f, svArray)
+		err := mc.readFile(ctx, f, svArray)
 		if err != nil {
 			return err
 		}
@@ -108,7 +135,7 @@ func hash64(s string) (uint64, error) {
 	return h.Sum64(), nil
 }
 
-func (mc *MysqlClient) readFile(fileInfo os.FileInfo, svArray []SchemaVersion) error {
+func (mc *MysqlClient) readFile(ctx context.Context, fileInfo os.FileInfo, svArray []SchemaVersion) error {
 	b, err := ioutil.ReadFile(fmt.Sprintf("%s/%s", mc.config.ddlPath, fileInfo.Name()))
 	if err != nil {
 		return err
@@ -130,7 +157,7 @@ func (mc *MysqlClient) readFile(fileInfo os.FileInfo, svArray []SchemaVersion) e
 		Checksum: strconv.FormatUint(checksum, 10),
 		Status:   schemaVersionStatusError,
 	}
-	err = mc.ExecDDL(string(b))
+	err = mc.ExecDDLContext(ctx, string(b))
 	if err == nil {
 		schemaVersion.Status = schemaVersionStatusSuccess
 	}
@@ -180,8 +207,14 @@ func (mc *MysqlClient) hasError(svArray []SchemaVersion) error {
 }
 
 func (mc *MysqlClient) SchemaVersionArray() ([]SchemaVersion, error) {
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.SchemaVersionArrayContext(ctx)
+}
+
+func (mc *MysqlClient) SchemaVersionArrayContext(ctx context.Context) ([]SchemaVersion, error) {
 	var svArray []SchemaVersion
-	err := mc.FindCustom(`select * from schema_version`, func(rows *sql.Rows) error {
+	err := mc.FindCustomContext(ctx, `select * from schema_version`, func(rows *sql.Rows) error {
 		var sv SchemaVersion
 		err := rows.Scan(&sv.Id, &sv.Script, &sv.Checksum, &sv.ExecutionTime, &sv.Status, &sv.CreatedTime)
 		svArray = append(svArray, sv)
@@ -196,24 +229,41 @@ func (mc *MysqlClient) SchemaVersionArray() ([]SchemaVersion, error) {
 	return svArray, nil
 }
 
-func (mc *MysqlClient) initialSchemaVersion() error {
-	exist, err := mc.HasTable("schema_version")
+func (mc *MysqlClient) initialSchemaVersion(ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, mc.config.timeout)
+	exist, err := mc.HasTableContext(timeoutCtx, "schema_version")
+	cancel()
 	if err != nil {
 		return err
 	}
 	if exist {
 		return nil
 	}
-	return mc.ExecDDL(ddlSchemaVersion)
+	return mc.ExecDDLContext(ctx, ddlSchemaVersion)
 }
 
 func (mc *MysqlClient) HasTable(tableName string) (bool, error) {
-	_, err := mc.GetDB().Query(fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", tableName))
-	if err != nil {
-		if strings.HasSuffix(err.Error(), "doesn't exist") {
-			return false, nil
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.HasTableContext(ctx, tableName)
+}
+
+func (mc *MysqlClient) HasTableContext(ctx context.Context, tableName string) (bool, error) {
+	call := &Call{Op: OpQuery, SQL: fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", tableName), Rows: -1}
+	err := mc.config.intercept(ctx, call, func(ctx context.Context, call *Call) error {
+		rows, err := mc.GetDB().QueryContext(ctx, call.SQL, call.Args...)
+		if err != nil {
+			return wrapError(err)
+		}
+		defer rows.Close()
+		call.Rows = 0
+		for rows.Next() {
+			call.Rows++
 		}
-		return true, err
+		return nil
+	})
+	if errors.Is(err, ErrTableNotExist) {
+		return false, nil
 	}
-	return true, nil
+	return true, err
 }
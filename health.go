@@ -0,0 +1,146 @@
+package mysqlclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHealthInterval         = 10 * time.Second
+	defaultHealthFailureThreshold = 3
+)
+
+type HealthState int
+
+const (
+	// Healthy means the last ping succeeded.
+	Healthy HealthState = iota
+	// Degraded means the last pings failed, fewer times in a row than the
+	// failure threshold.
+	Degraded
+	// Down means the pings failed at least the failure threshold in a row.
+	Down
+)
+
+func (s HealthState) String() string {
+	switch s {
+	case Healthy:
+		return "healthy"
+	case Degraded:
+		return "degraded"
+	case Down:
+		return "down"
+	}
+	return "unknown"
+}
+
+type HealthStatus struct {
+	State HealthState
+	// Failures is the number of failed pings in a row.
+	Failures  int
+	LastError error
+	LastCheck time.Time
+}
+
+// HealthFunc is called with the previous and the new status when the state
+// changes.
+type HealthFunc func(previous, current HealthStatus)
+
+// HealthCheckConfig configures the health monitor of the HealthCheck option.
+type HealthCheckConfig struct {
+	// Interval between two pings, 10s by default.
+	Interval time.Duration
+	// FailureThreshold is the number of failed pings in a row marking the
+	// client Down, 3 by default.
+	FailureThreshold int
+	OnChange         []HealthFunc
+}
+
+type healthMonitor struct {
+	config HealthCheckConfig
+	mu     sync.Mutex
+	status HealthStatus
+	funcs  []HealthFunc
+}
+
+func newHealthMonitor(config HealthCheckConfig) *healthMonitor {
+	if config.Interval <= 0 {
+		config.Interval = defaultHealthInterval
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaultHealthFailureThreshold
+	}
+	return &healthMonitor{config: config, funcs: append([]HealthFunc(nil), config.OnChange...)}
+}
+
+// record updates the status with the result of a ping and calls the
+// HealthFuncs if the state changed.
+func (m *healthMonitor) record(err error, now time.Time) {
+	m.mu.Lock()
+	previous := m.status
+	current := HealthStatus{State: Healthy, LastError: err, LastCheck: now}
+	if err != nil {
+		current.Failures = previous.Failures + 1
+		current.State = Degraded
+		if current.Failures >= m.config.FailureThreshold {
+			current.State = Down
+		}
+	}
+	m.status = current
+	funcs := m.funcs
+	m.mu.Unlock()
+	if current.State == previous.State {
+		return
+	}
+	for _, fn := range funcs {
+		fn(previous, current)
+	}
+}
+
+func (m *healthMonitor) onChange(fn HealthFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.funcs = append(m.funcs, fn)
+}
+
+func (m *healthMonitor) getStatus() HealthStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+func (mc *MysqlClient) checkHealth() {
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	mc.health.record(mc.PingContext(ctx), time.Now())
+}
+
+// Health returns the status kept by the health monitor. Without the
+// HealthCheck option it pings the primary pool, the client is then either
+// Healthy or Down.
+func (mc *MysqlClient) Health() HealthStatus {
+	if mc.health != nil {
+		return mc.health.getStatus()
+	}
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.pingHealth(ctx)
+}
+
+func (mc *MysqlClient) pingHealth(ctx context.Context) HealthStatus {
+	status := HealthStatus{State: Healthy, LastCheck: time.Now()}
+	if err := mc.PingContext(ctx); err != nil {
+		status = HealthStatus{State: Down, Failures: 1, LastError: err, LastCheck: status.LastCheck}
+	}
+	return status
+}
+
+// OnHealthChange registers fn to be called by the health monitor on every
+// state change, from its goroutine. It does nothing without the HealthCheck
+// option.
+func (mc *MysqlClient) OnHealthChange(fn HealthFunc) {
+	if mc.health != nil {
+		mc.health.onChange(fn)
+	}
+}
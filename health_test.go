@@ -0,0 +1,42 @@
+package mysqlclient
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	var transitions []string
+	monitor := newHealthMonitor(HealthCheckConfig{
+		FailureThreshold: 2,
+		OnChange: []HealthFunc{func(previous, current HealthStatus) {
+			transitions = append(transitions, previous.State.String()+"->"+current.State.String())
+		}},
+	})
+	assert.Equal(t, defaultHealthInterval, monitor.config.Interval)
+	down := errors.New("down")
+	now := time.Now()
+	var testCases = []struct {
+		err      error
+		state    HealthState
+		failures int
+	}{
+		{err: nil, state: Healthy, failures: 0},
+		{err: down, state: Degraded, failures: 1},
+		{err: down, state: Down, failures: 2},
+		{err: down, state: Down, failures: 3},
+		{err: nil, state: Healthy, failures: 0},
+	}
+	for _, test := range testCases {
+		monitor.record(test.err, now)
+		status := monitor.getStatus()
+		assert.Equal(t, test.state, status.State)
+		assert.Equal(t, test.failures, status.Failures)
+		assert.Equal(t, test.err, status.LastError)
+		assert.Equal(t, now, status.LastCheck)
+	}
+	assert.Equal(t, []string{"healthy->degraded", "degraded->down", "down->healthy"}, transitions)
+	assert.Equal(t, "unknown", HealthState(9).String())
+}
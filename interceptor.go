@@ -0,0 +1,70 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Operation is the kind of call seen by an Interceptor.
+type Operation string
+
+const (
+	OpExec        Operation = "exec"
+	OpQuery       Operation = "query"
+	OpCount       Operation = "count"
+	OpTransaction Operation = "transaction"
+	OpDDL         Operation = "ddl"
+)
+
+// Call describes one statement, or one whole transaction for OpTransaction,
+// passing through the interceptor chain. Interceptors may change SQL and
+// Args before calling next; the remaining fields are set once next returns.
+type Call struct {
+	Op   Operation
+	SQL  string
+	Args []interface{}
+	// InTx is true for statements run by a Tx and for nested transactions.
+	InTx bool
+
+	// Result is set for OpExec and OpDDL.
+	Result sql.Result
+	// Rows is the number of rows affected by OpExec and OpDDL or read by
+	// OpQuery and OpCount, -1 when unknown (e.g. QueryCursor).
+	Rows     int64
+	Duration time.Duration
+}
+
+// Handler runs a call, either the next interceptor or the statement itself.
+type Handler func(ctx context.Context, call *Call) error
+
+// Interceptor wraps every call made by MysqlClient and Tx. It must call next
+// to run the statement, or return an error to abort it. Interceptors run in
+// the order they were installed, the first one outermost.
+type Interceptor interface {
+	Intercept(ctx context.Context, call *Call, next Handler) error
+}
+
+type InterceptorFunc func(ctx context.Context, call *Call, next Handler) error
+
+func (f InterceptorFunc) Intercept(ctx context.Context, call *Call, next Handler) error {
+	return f(ctx, call, next)
+}
+
+// intercept runs handler through the installed interceptors and records its
+// duration in call.
+func (c *Config) intercept(ctx context.Context, call *Call, handler Handler) error {
+	h := func(ctx context.Context, call *Call) error {
+		startT := time.Now()
+		err := handler(ctx, call)
+		call.Duration = time.Since(startT)
+		return err
+	}
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := c.interceptors[i], h
+		h = func(ctx context.Context, call *Call) error {
+			return interceptor.Intercept(ctx, call, next)
+		}
+	}
+	return h(ctx, call)
+}
@@ -0,0 +1,56 @@
+package mysqlclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Intercept(t *testing.T) {
+	var trace []string
+	named := func(name string) Interceptor {
+		return InterceptorFunc(func(ctx context.Context, call *Call, next Handler) error {
+			trace = append(trace, name+" before")
+			call.SQL += " /* " + name + " */"
+			err := next(ctx, call)
+			trace = append(trace, name+" after")
+			return err
+		})
+	}
+	config := &Config{}
+	Interceptors(named("a"), named("b"))(config)
+	call := &Call{Op: OpQuery, SQL: "SELECT 1"}
+	err := config.intercept(context.Background(), call, func(ctx context.Context, call *Call) error {
+		trace = append(trace, call.SQL)
+		call.Rows = 1
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"a before", "b before", "SELECT 1 /* a */ /* b */", "b after", "a after"}, trace)
+	assert.EqualValues(t, 1, call.Rows)
+}
+
+func TestConfig_Intercept_Abort(t *testing.T) {
+	errDenied := errors.New("denied")
+	config := &Config{}
+	Interceptors(InterceptorFunc(func(ctx context.Context, call *Call, next Handler) error {
+		if call.Op == OpExec {
+			return errDenied
+		}
+		return next(ctx, call)
+	}))(config)
+	called := false
+	handler := func(ctx context.Context, call *Call) error {
+		called = true
+		return nil
+	}
+	err := config.intercept(context.Background(), &Call{Op: OpExec, SQL: "DELETE FROM t"}, handler)
+	assert.Equal(t, errDenied, err)
+	assert.False(t, called)
+
+	err = config.intercept(context.Background(), &Call{Op: OpQuery, SQL: "SELECT 1"}, handler)
+	assert.Nil(t, err)
+	assert.True(t, called)
+}
@@ -0,0 +1,365 @@
+package mysqlclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sillyhatxu/db-client/builder"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor token")
+
+const (
+	cursorNext = "n"
+	cursorPrev = "p"
+)
+
+// KeysetQuery selects a page of rows ordered by Keys, which must be NOT
+// NULL columns making the order unique, usually ending with the primary key.
+type KeysetQuery struct {
+	// Where is taken as by builder.BuildSelect, without the special keys.
+	Where map[string]interface{}
+	Keys  []string
+	Desc  bool
+	// Limit is the page size, capped by the MaxPageSize of the client, 20
+	// is used when it is not positive.
+	Limit int
+	// Cursor is the Next or Prev token of a previous page, empty for the
+	// first page.
+	Cursor string
+}
+
+// KeysetPage holds the tokens to read the pages around the one returned,
+// they are empty when there is no such page.
+type KeysetPage struct {
+	Next string
+	Prev string
+}
+
+// cursorToken is the signed payload of a cursor. Table, Where, the hash of
+// the conditions, Keys and Desc bind it to the query that issued it.
+type cursorToken struct {
+	Direction string   `json:"d"`
+	Table     string   `json:"t"`
+	Where     string   `json:"w"`
+	Keys      []string `json:"k"`
+	Desc      bool     `json:"s"`
+	Values    []string `json:"v"`
+}
+
+// keysetScope returns the token fields identifying the query on table.
+func keysetScope(table string, query KeysetQuery) (cursorToken, error) {
+	where, err := json.Marshal(query.Where)
+	if err != nil {
+		return cursorToken{}, fmt.Errorf("cannot hash the conditions of the cursor: %w", err)
+	}
+	sum := sha256.Sum256(where)
+	return cursorToken{
+		Table: table,
+		Where: base64.RawURLEncoding.EncodeToString(sum[:]),
+		Keys:  query.Keys,
+		Desc:  query.Desc,
+	}, nil
+}
+
+func newCursorSecret() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+func (mc *MysqlClient) FindKeyset(table string, query KeysetQuery, output interface{}) (KeysetPage, error) {
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.FindKeysetContext(ctx, table, query, output)
+}
+
+// FindKeysetContext reads the page of query into output, a pointer to a
+// slice of structs or of map[string]interface{}, and returns the cursor
+// tokens of the next and previous pages. Rows are always in the order of
+// Keys, whichever way the page was reached.
+func (mc *MysqlClient) FindKeysetContext(ctx context.Context, table string, query KeysetQuery, output interface{}) (KeysetPage, error) {
+	var page KeysetPage
+	outVal := reflect.ValueOf(output)
+	if outVal.Kind() != reflect.Ptr || outVal.Elem().Kind() != reflect.Slice {
+		return page, fmt.Errorf("output must be a pointer to a slice, got %T", output)
+	}
+	limit := mc.config.pageSize(query.Limit)
+	scope, err := keysetScope(table, query)
+	if err != nil {
+		return page, err
+	}
+	direction := cursorNext
+	var values []interface{}
+	if query.Cursor != "" {
+		token, err := decodeCursor(mc.config.cursorSecret, query.Cursor, scope)
+		if err != nil {
+			return page, err
+		}
+		direction = token.Direction
+		values, err = decodeCursorValues(token.Values)
+		if err != nil {
+			return page, err
+		}
+	}
+	selectSQL, args, err := buildKeysetSelect(table, query, direction, values, limit)
+	if err != nil {
+		return page, err
+	}
+	if err := mc.readExecutor(ctx).find(ctx, selectSQL, output, args...); err != nil {
+		return page, err
+	}
+	rows := outVal.Elem()
+	hasMore := rows.Len() > limit
+	if hasMore {
+		rows.Set(rows.Slice(0, limit))
+	}
+	if direction == cursorPrev {
+		reverseSlice(rows)
+	}
+	if rows.Len() == 0 {
+		return page, nil
+	}
+	hasNext, hasPrev := hasMore, query.Cursor != ""
+	if direction == cursorPrev {
+		hasNext, hasPrev = true, hasMore
+	}
+	if hasNext {
+		page.Next, err = mc.rowCursor(cursorNext, scope, rows.Index(rows.Len()-1))
+		if err != nil {
+			return page, err
+		}
+	}
+	if hasPrev {
+		page.Prev, err = mc.rowCursor(cursorPrev, scope, rows.Index(0))
+		if err != nil {
+			return page, err
+		}
+	}
+	return page, nil
+}
+
+// buildKeysetSelect selects limit+1 rows after values, or before them when
+// going to the previous page, which reads the rows in reverse order.
+func buildKeysetSelect(table string, query KeysetQuery, direction string, values []interface{}, limit int) (string, []interface{}, error) {
+	if len(query.Keys) == 0 {
+		return "", nil, errors.New("keyset pagination needs at least one key")
+	}
+	where, err := builder.Conditions(query.Where)
+	if err != nil {
+		return "", nil, err
+	}
+	desc := query.Desc
+	if direction == cursorPrev {
+		desc = !desc
+	}
+	if values != nil {
+		where = append(where, builder.Seek{Columns: query.Keys, Values: values, Desc: desc})
+	}
+	order := "ASC"
+	if desc {
+		order = "DESC"
+	}
+	orderBy := make([]string, len(query.Keys))
+	for i, key := range query.Keys {
+		orderBy[i] = key + " " + order
+	}
+	return builder.BuildSelectQuery(builder.SelectQuery{
+		Table:   table,
+		Where:   where,
+		OrderBy: strings.Join(orderBy, ","),
+		Limit:   []uint{uint(limit) + 1},
+	})
+}
+
+func reverseSlice(rows reflect.Value) {
+	swap := reflect.Swapper(rows.Interface())
+	for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
+
+// rowCursor returns the token of the keys of row, a struct or a map. Keys
+// are matched to the columns as when decoding, ignoring the case when there
+// is no exact match.
+func (mc *MysqlClient) rowCursor(direction string, scope cursorToken, row reflect.Value) (string, error) {
+	for row.Kind() == reflect.Ptr || row.Kind() == reflect.Interface {
+		row = row.Elem()
+	}
+	keys := scope.Keys
+	values := make([]interface{}, len(keys))
+	switch row.Kind() {
+	case reflect.Map:
+		for i, key := range keys {
+			value := mapIndexFold(row, key)
+			if !value.IsValid() {
+				return "", fmt.Errorf("key %s is not selected", key)
+			}
+			values[i] = value.Interface()
+		}
+	case reflect.Struct:
+		model, err := getCrudModel(row.Type())
+		if err != nil {
+			return "", err
+		}
+		for i, key := range keys {
+			field, ok := model.field(key)
+			if !ok {
+				return "", fmt.Errorf("key %s has no field in %s", key, row.Type())
+			}
+			values[i] = row.FieldByIndex(field.index).Interface()
+		}
+	default:
+		return "", fmt.Errorf("cannot read keys from %s", row.Type())
+	}
+	encoded, err := encodeCursorValues(values)
+	if err != nil {
+		return "", err
+	}
+	token := scope
+	token.Direction, token.Values = direction, encoded
+	return encodeCursor(mc.config.cursorSecret, token)
+}
+
+func mapIndexFold(row reflect.Value, key string) reflect.Value {
+	if value := row.MapIndex(reflect.ValueOf(key)); value.IsValid() {
+		return value
+	}
+	for _, k := range row.MapKeys() {
+		if strings.EqualFold(k.String(), key) {
+			return row.MapIndex(k)
+		}
+	}
+	return reflect.Value{}
+}
+
+// encodeCursor returns the token as base64url(payload).base64url(hmac).
+func encodeCursor(secret []byte, token cursorToken) (string, error) {
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(signCursor(secret, payload)), nil
+}
+
+// decodeCursor checks the signature of s and that it was issued for the
+// query of scope.
+func decodeCursor(secret []byte, s string, scope cursorToken) (cursorToken, error) {
+	var token cursorToken
+	parts := strings.Split(s, ".")
+	if len(parts) != 2 {
+		return token, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return token, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, signCursor(secret, payload)) {
+		return token, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &token); err != nil {
+		return token, ErrInvalidCursor
+	}
+	if token.Direction != cursorNext && token.Direction != cursorPrev ||
+		token.Table != scope.Table || token.Where != scope.Where || token.Desc != scope.Desc ||
+		!reflect.DeepEqual(token.Keys, scope.Keys) || len(token.Values) != len(scope.Keys) {
+		return token, ErrInvalidCursor
+	}
+	return token, nil
+}
+
+func signCursor(secret, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// encodeCursorValues writes every value as a type letter followed by the
+// value, so that it is decoded back to the same type.
+func encodeCursorValues(values []interface{}) ([]string, error) {
+	encoded := make([]string, len(values))
+	for i, value := range values {
+		v := reflect.ValueOf(value)
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				break
+			}
+			v = v.Elem()
+		}
+		if !v.IsValid() || v.Kind() == reflect.Ptr {
+			encoded[i] = "n"
+			continue
+		}
+		if t, ok := v.Interface().(time.Time); ok {
+			encoded[i] = "t" + t.Format(time.RFC3339Nano)
+			continue
+		}
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			encoded[i] = "i" + strconv.FormatInt(v.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			encoded[i] = "u" + strconv.FormatUint(v.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			encoded[i] = "f" + strconv.FormatFloat(v.Float(), 'g', -1, 64)
+		case reflect.String:
+			encoded[i] = "s" + v.String()
+		case reflect.Bool:
+			encoded[i] = "o" + strconv.FormatBool(v.Bool())
+		case reflect.Slice:
+			if v.Type().Elem().Kind() != reflect.Uint8 {
+				return nil, fmt.Errorf("cannot use %s as a cursor key", v.Type())
+			}
+			encoded[i] = "b" + base64.StdEncoding.EncodeToString(v.Bytes())
+		default:
+			return nil, fmt.Errorf("cannot use %s as a cursor key", v.Type())
+		}
+	}
+	return encoded, nil
+}
+
+func decodeCursorValues(encoded []string) ([]interface{}, error) {
+	values := make([]interface{}, len(encoded))
+	for i, s := range encoded {
+		if s == "" {
+			return nil, ErrInvalidCursor
+		}
+		var err error
+		switch kind, value := s[0], s[1:]; kind {
+		case 'n':
+			values[i] = nil
+		case 'i':
+			values[i], err = strconv.ParseInt(value, 10, 64)
+		case 'u':
+			values[i], err = strconv.ParseUint(value, 10, 64)
+		case 'f':
+			values[i], err = strconv.ParseFloat(value, 64)
+		case 's':
+			values[i] = value
+		case 'o':
+			values[i], err = strconv.ParseBool(value)
+		case 'b':
+			values[i], err = base64.StdEncoding.DecodeString(value)
+		case 't':
+			values[i], err = time.Parse(time.RFC3339Nano, value)
+		default:
+			err = ErrInvalidCursor
+		}
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,141 @@
+package mysqlclient
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildKeysetSelect(t *testing.T) {
+	query := KeysetQuery{
+		Where: map[string]interface{}{"status": true},
+		Keys:  []string{"created_time", "id"},
+	}
+	var testCases = []struct {
+		direction string
+		desc      bool
+		values    []interface{}
+		sql       string
+		args      []interface{}
+	}{
+		{
+			direction: cursorNext,
+			sql:       "SELECT * FROM user WHERE (status=?) ORDER BY created_time ASC,id ASC LIMIT ?,?",
+			args:      []interface{}{true, 0, 11},
+		},
+		{
+			direction: cursorNext,
+			values:    []interface{}{"2020-01-01", int64(7)},
+			sql:       "SELECT * FROM user WHERE (status=? AND (created_time,id)>(?,?)) ORDER BY created_time ASC,id ASC LIMIT ?,?",
+			args:      []interface{}{true, "2020-01-01", int64(7), 0, 11},
+		},
+		{
+			direction: cursorPrev,
+			values:    []interface{}{"2020-01-01", int64(7)},
+			sql:       "SELECT * FROM user WHERE (status=? AND (created_time,id)<(?,?)) ORDER BY created_time DESC,id DESC LIMIT ?,?",
+			args:      []interface{}{true, "2020-01-01", int64(7), 0, 11},
+		},
+		{
+			direction: cursorPrev,
+			desc:      true,
+			values:    []interface{}{"2020-01-01", int64(7)},
+			sql:       "SELECT * FROM user WHERE (status=? AND (created_time,id)>(?,?)) ORDER BY created_time ASC,id ASC LIMIT ?,?",
+			args:      []interface{}{true, "2020-01-01", int64(7), 0, 11},
+		},
+	}
+	for _, test := range testCases {
+		query.Desc = test.desc
+		sql, args, err := buildKeysetSelect("user", query, test.direction, test.values, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, test.sql, sql)
+		assert.EqualValues(t, test.args, args)
+	}
+	_, _, err := buildKeysetSelect("user", KeysetQuery{}, cursorNext, nil, 10)
+	assert.NotNil(t, err)
+}
+
+func TestCursorToken(t *testing.T) {
+	secret := []byte("secret")
+	created := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	id := int64(7)
+	values := []interface{}{created, &id, uint8(1), 1.5, "a.b", []byte{0, 1}, true, nil}
+	keys := []string{"t", "i", "u", "f", "s", "b", "o", "n"}
+	query := KeysetQuery{Where: map[string]interface{}{"status": true}, Keys: keys}
+	scope, err := keysetScope("user", query)
+	assert.Nil(t, err)
+	encoded, err := encodeCursorValues(values)
+	assert.Nil(t, err)
+	issued := scope
+	issued.Direction, issued.Values = cursorNext, encoded
+	token, err := encodeCursor(secret, issued)
+	assert.Nil(t, err)
+
+	decoded, err := decodeCursor(secret, token, scope)
+	assert.Nil(t, err)
+	assert.Equal(t, cursorNext, decoded.Direction)
+	decodedValues, err := decodeCursorValues(decoded.Values)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []interface{}{created, int64(7), uint64(1), 1.5, "a.b", []byte{0, 1}, true, nil}, decodedValues)
+
+	_, err = decodeCursor([]byte("other"), token, scope)
+	assert.Equal(t, ErrInvalidCursor, err)
+	parts := strings.Split(token, ".")
+	_, err = decodeCursor(secret, parts[0]+"x."+parts[1], scope)
+	assert.Equal(t, ErrInvalidCursor, err)
+	_, err = decodeCursor(secret, "garbage", scope)
+	assert.Equal(t, ErrInvalidCursor, err)
+
+	// a token only pages through the query that issued it
+	others := []struct {
+		table string
+		query KeysetQuery
+	}{
+		{table: "user", query: KeysetQuery{Where: query.Where, Keys: []string{"id"}}},
+		{table: "account", query: query},
+		{table: "user", query: KeysetQuery{Where: map[string]interface{}{"status": false}, Keys: keys}},
+		{table: "user", query: KeysetQuery{Keys: keys}},
+		{table: "user", query: KeysetQuery{Where: query.Where, Keys: keys, Desc: true}},
+	}
+	for _, other := range others {
+		otherScope, err := keysetScope(other.table, other.query)
+		assert.Nil(t, err)
+		_, err = decodeCursor(secret, token, otherScope)
+		assert.Equal(t, ErrInvalidCursor, err, "%+v", other)
+	}
+	sameScope, err := keysetScope("user", KeysetQuery{Where: map[string]interface{}{"status": true}, Keys: keys, Limit: 5})
+	assert.Nil(t, err)
+	_, err = decodeCursor(secret, token, sameScope)
+	assert.Nil(t, err)
+
+	_, err = encodeCursorValues([]interface{}{[]int{1}})
+	assert.NotNil(t, err)
+}
+
+func TestRowCursor_KeyCase(t *testing.T) {
+	type user struct {
+		Id   int64  `column:"id"`
+		Name string `column:"name"`
+	}
+	mc := &MysqlClient{config: &Config{cursorSecret: []byte("secret")}}
+	scope, err := keysetScope("user", KeysetQuery{Keys: []string{"ID"}})
+	assert.Nil(t, err)
+	rows := []interface{}{
+		user{Id: 7, Name: "a"},
+		&user{Id: 7, Name: "a"},
+		map[string]interface{}{"id": int64(7), "name": "a"},
+	}
+	for _, row := range rows {
+		token, err := mc.rowCursor(cursorNext, scope, reflect.ValueOf(row))
+		assert.Nil(t, err, "%T", row)
+		decoded, err := decodeCursor(mc.config.cursorSecret, token, scope)
+		assert.Nil(t, err)
+		values, err := decodeCursorValues(decoded.Values)
+		assert.Nil(t, err)
+		assert.EqualValues(t, []interface{}{int64(7)}, values)
+	}
+	scope.Keys = []string{"missing"}
+	_, err = mc.rowCursor(cursorNext, scope, reflect.ValueOf(rows[0]))
+	assert.NotNil(t, err)
+}
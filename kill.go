@@ -0,0 +1,223 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"sync"
+	"time"
+)
+
+var (
+	errNoKillConn   = errors.New("no connection reserved for KILL QUERY")
+	errKillPoolSize = errors.New("KillOnTimeout needs a MaxOpenConns of at least 2, one connection is reserved for KILL QUERY")
+)
+
+// KillError is returned instead of the context error when KillOnTimeout
+// tried to kill the query on the server. errors.Is(err, TimeOutError) holds
+// when the query ran out of time.
+type KillError struct {
+	// ConnectionID is the CONNECTION_ID() the query was running on.
+	ConnectionID int64
+	// Killed reports whether KILL QUERY succeeded, KillErr is its error
+	// otherwise.
+	Killed  bool
+	KillErr error
+	Err     error
+}
+
+func (e *KillError) Error() string {
+	if e.Killed {
+		return fmt.Sprintf("%v, killed query on connection %d", e.Err, e.ConnectionID)
+	}
+	return fmt.Sprintf("%v, failed to kill query on connection %d: %v", e.Err, e.ConnectionID, e.KillErr)
+}
+
+func (e *KillError) Unwrap() error {
+	return e.Err
+}
+
+// killConn is the connection of a pool reserved for KILL QUERY.
+type killConn struct {
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// killConns reserves a connection per pool for KILL QUERY, so that a kill is
+// not queued behind the statements filling the pool at the time it is most
+// needed.
+type killConns struct {
+	mu    sync.Mutex
+	conns map[*sql.DB]*killConn
+}
+
+func newKillConns() *killConns {
+	return &killConns{conns: make(map[*sql.DB]*killConn)}
+}
+
+func (k *killConns) get(db *sql.DB) *killConn {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	conn, ok := k.conns[db]
+	if !ok {
+		conn = &killConn{}
+		k.conns[db] = conn
+	}
+	return conn
+}
+
+// reserve takes the connection of db unless it already holds one, waiting
+// for it as any call would.
+func (k *killConns) reserve(ctx context.Context, db *sql.DB) error {
+	kc := k.get(db)
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if kc.conn != nil {
+		return nil
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	kc.conn = conn
+	return nil
+}
+
+// kill runs KILL QUERY id on the connection of db. A failed connection is
+// dropped, the next call reserves another one.
+func (k *killConns) kill(db *sql.DB, id int64, timeout time.Duration) error {
+	kc := k.get(db)
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	if kc.conn == nil {
+		return errNoKillConn
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err := kc.conn.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", id))
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if !errors.As(err, &mysqlErr) {
+			_ = kc.conn.Close()
+			kc.conn = nil
+		}
+	}
+	return err
+}
+
+func (k *killConns) close() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for db, kc := range k.conns {
+		kc.mu.Lock()
+		if kc.conn != nil {
+			_ = kc.conn.Close()
+			kc.conn = nil
+		}
+		kc.mu.Unlock()
+		delete(k.conns, db)
+	}
+}
+
+// checkKillPools rejects the pools limited to one connection, every call
+// would wait forever behind the reserved kill connection. 0 is unlimited.
+func checkKillPools(pools ...*sql.DB) error {
+	for _, pool := range pools {
+		if pool.Stats().MaxOpenConnections == 1 {
+			return errKillPoolSize
+		}
+	}
+	return nil
+}
+
+// queryKiller holds a connection of db for the statements of one call and
+// runs KILL QUERY on the reserved connection once ctx is done, as cancelling
+// ctx only makes the driver drop the connection while the server goes on.
+type queryKiller struct {
+	ctx     context.Context
+	db      *sql.DB
+	conn    *sql.Conn
+	id      int64
+	kills   *killConns
+	timeout time.Duration
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+	killErr error
+	tried   bool
+}
+
+// startQueryKiller reserves the kill connection of db before taking the
+// one of the statements, so that calls waiting for a kill connection never
+// hold a statement connection.
+func startQueryKiller(ctx context.Context, db *sql.DB, kills *killConns, timeout time.Duration) (*queryKiller, error) {
+	if err := kills.reserve(ctx, db); err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	k := &queryKiller{ctx: ctx, db: db, conn: conn, kills: kills, timeout: timeout, done: make(chan struct{})}
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&k.id); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		select {
+		case <-ctx.Done():
+			k.kill()
+		case <-k.done:
+		}
+	}()
+	return k, nil
+}
+
+func (k *queryKiller) kill() {
+	k.once.Do(func() {
+		err := k.kills.kill(k.db, k.id, k.timeout)
+		k.tried, k.killErr = true, wrapError(err)
+	})
+}
+
+// stop releases the connection. err is the result of the statements, it is
+// turned into a *KillError when they failed because ctx was done.
+func (k *queryKiller) stop(err error) error {
+	close(k.done)
+	k.wg.Wait()
+	if err != nil && k.ctx.Err() != nil {
+		k.kill()
+	}
+	_ = k.conn.Close()
+	if err == nil || !k.tried {
+		return err
+	}
+	return &KillError{ConnectionID: k.id, Killed: k.killErr == nil, KillErr: k.killErr, Err: wrapError(k.ctx.Err())}
+}
+
+// pin returns an executor running on one connection watched by a
+// queryKiller when KillOnTimeout is on, and the function to call with the
+// result once done. Transactions are not pinned, their statements are never
+// killed.
+func (e *executor) pin(ctx context.Context) (*executor, func(error) error, error) {
+	if e.kills == nil || e.inTx {
+		return e, func(err error) error { return err }, nil
+	}
+	killer, err := startQueryKiller(ctx, e.db, e.kills, e.config.timeout)
+	if err != nil {
+		return nil, nil, wrapError(err)
+	}
+	pinned := *e
+	pinned.q = killer.conn
+	pinned.stmts = nil
+	return &pinned, killer.stop, nil
+}
+
+func isKillError(err error) bool {
+	var killErr *KillError
+	return errors.As(err, &killErr)
+}
@@ -0,0 +1,96 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/sillyhatxu/db-client/dbclient"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestKillError(t *testing.T) {
+	killed := &KillError{ConnectionID: 12, Killed: true, Err: TimeOutError}
+	assert.True(t, errors.Is(killed, TimeOutError))
+	assert.EqualError(t, killed, "database connect timeout, killed query on connection 12")
+
+	failed := &KillError{ConnectionID: 12, KillErr: errors.New("access denied"), Err: context.Canceled}
+	assert.True(t, errors.Is(failed, context.Canceled))
+	assert.False(t, errors.Is(failed, TimeOutError))
+	assert.EqualError(t, failed, "context canceled, failed to kill query on connection 12: access denied")
+	assert.True(t, isKillError(failed))
+	assert.False(t, isKillError(TimeOutError))
+}
+
+func TestExecutorPin(t *testing.T) {
+	e := &executor{config: &Config{}}
+	pinned, unpin, err := e.pin(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, e, pinned)
+	assert.Equal(t, TimeOutError, unpin(TimeOutError))
+
+	tx := &executor{config: &Config{killOnTimeout: true}, kills: newKillConns(), inTx: true}
+	pinned, _, err = tx.pin(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, tx, pinned)
+}
+
+func TestKillConns_NotReserved(t *testing.T) {
+	kills := newKillConns()
+	assert.Equal(t, errNoKillConn, kills.kill(nil, 12, time.Second))
+	kills.close()
+}
+
+func TestCheckKillPools(t *testing.T) {
+	unlimited, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+	assert.Nil(t, err)
+	defer unlimited.Close()
+	single, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+	assert.Nil(t, err)
+	defer single.Close()
+	single.SetMaxOpenConns(1)
+	assert.Nil(t, checkKillPools(unlimited))
+	assert.Equal(t, errKillPoolSize, checkKillPools(unlimited, single))
+	single.SetMaxOpenConns(2)
+	assert.Nil(t, checkKillPools(unlimited, single))
+
+	single.SetMaxOpenConns(1)
+	_, err = NewMysqlClient(Pool(unlimited), Replicas(single), KillOnTimeout(true))
+	assert.Equal(t, errKillPoolSize, err)
+}
+
+func TestMysqlClient_KillOnTimeoutFullPool(t *testing.T) {
+	pool, err := dbclient.NewDBClient(
+		dbclient.UserName(userName),
+		dbclient.Password(password),
+		dbclient.Host(host),
+		dbclient.Port(port),
+		dbclient.Schema(schema),
+		dbclient.MaxOpenConns(2),
+	)
+	assert.Nil(t, err)
+	mc, err := NewMysqlClient(Pool(pool), KillOnTimeout(true), Timeout(500*time.Millisecond))
+	assert.Nil(t, err)
+	defer mc.Close(context.Background())
+	// the reserved kill connection and the sleeping statement fill the pool,
+	// the second call waits for a connection until its own timeout
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := mc.Count("select sleep(5)")
+			errs <- err
+		}()
+	}
+	killed := 0
+	for i := 0; i < 2; i++ {
+		err := <-errs
+		assert.True(t, errors.Is(err, TimeOutError))
+		var killErr *KillError
+		if errors.As(err, &killErr) {
+			assert.True(t, killErr.Killed, "%v", killErr)
+			killed++
+		}
+	}
+	assert.Equal(t, 1, killed)
+}
@@ -0,0 +1,71 @@
+package mysqlclient
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sillyhatxu/db-client/metrics"
+)
+
+const (
+	statementDurationName = "db_client_statement_duration_seconds"
+	primaryPoolName       = "primary"
+	replicaPoolPrefix     = "replica_"
+)
+
+type metricsInterceptor struct {
+	client   string
+	duration *metrics.Histogram
+}
+
+func newMetricsInterceptor(registry *metrics.Registry, client string) *metricsInterceptor {
+	return &metricsInterceptor{
+		client:   client,
+		duration: registry.Histogram(statementDurationName, "Latency of statements and transactions by operation.", nil, "client", "operation", "status"),
+	}
+}
+
+func (i *metricsInterceptor) Intercept(ctx context.Context, call *Call, next Handler) error {
+	err := next(ctx, call)
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	i.duration.Observe(call.Duration.Seconds(), i.client, metricsOperation(call), status)
+	return err
+}
+
+// metricsOperation labels a call insert, update, delete, find, count, tx,
+// savepoint, ddl or exec for other statements.
+func metricsOperation(call *Call) string {
+	switch call.Op {
+	case OpCount:
+		return "count"
+	case OpTransaction:
+		//a nested transaction is a call with the SAVEPOINT statement
+		if fields := strings.Fields(call.SQL); len(fields) > 0 {
+			switch strings.ToLower(fields[0]) {
+			case "savepoint", "release", "rollback":
+				return "savepoint"
+			}
+		}
+		return "tx"
+	case OpDDL:
+		return "ddl"
+	case OpQuery:
+		return "find"
+	}
+	fields := strings.Fields(call.SQL)
+	if len(fields) == 0 {
+		return "exec"
+	}
+	switch verb := strings.ToLower(fields[0]); verb {
+	case "insert", "replace":
+		return "insert"
+	case "update", "delete":
+		return verb
+	case "select":
+		return "find"
+	}
+	return "exec"
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// RegisterDBStats exports the sql.DB.Stats() of pool, such as the one
+// created by dbclient.NewDBClient, under the given client and pool labels.
+// The stats are read on every Collect. The returned function stops reading
+// them and drops their series, so that the labels can be registered again
+// once pool is closed.
+func RegisterDBStats(r *Registry, pool *sql.DB, client, name string) func() {
+	maxOpen := r.Gauge("db_client_pool_max_open_connections", "Maximum number of open connections to the database.", "client", "pool")
+	open := r.Gauge("db_client_pool_open_connections", "Number of established connections, both in use and idle.", "client", "pool")
+	inUse := r.Gauge("db_client_pool_in_use_connections", "Number of connections currently in use.", "client", "pool")
+	idle := r.Gauge("db_client_pool_idle_connections", "Number of idle connections.", "client", "pool")
+	waitCount := r.Counter("db_client_pool_wait_count_total", "Total number of connections waited for.", "client", "pool")
+	waitDuration := r.Counter("db_client_pool_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", "client", "pool")
+	//a Collect already running must not set the series again once dropped
+	var mu sync.Mutex
+	var stopped bool
+	unregister := r.RegisterCollector(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return
+		}
+		stats := pool.Stats()
+		maxOpen.Set(float64(stats.MaxOpenConnections), client, name)
+		open.Set(float64(stats.OpenConnections), client, name)
+		inUse.Set(float64(stats.InUse), client, name)
+		idle.Set(float64(stats.Idle), client, name)
+		waitCount.Set(float64(stats.WaitCount), client, name)
+		waitDuration.Set(stats.WaitDuration.Seconds(), client, name)
+	})
+	return func() {
+		unregister()
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		maxOpen.Delete(client, name)
+		open.Delete(client, name)
+		inUse.Delete(client, name)
+		idle.Delete(client, name)
+		waitCount.Delete(client, name)
+		waitDuration.Delete(client, name)
+	}
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"database/sql"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRegisterDBStats_SharedRegistry(t *testing.T) {
+	r := NewRegistry()
+	for i, client := range []string{"orders", "users"} {
+		pool, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/"+client)
+		assert.Nil(t, err)
+		pool.SetMaxOpenConns(i + 1)
+		defer pool.Close()
+		RegisterDBStats(r, pool, client, "primary")
+	}
+	for _, metric := range r.Collect() {
+		if metric.Name != "db_client_pool_max_open_connections" {
+			continue
+		}
+		assert.Equal(t, []Series{
+			{Labels: []Label{{Name: "client", Value: "orders"}, {Name: "pool", Value: "primary"}}, Value: 1},
+			{Labels: []Label{{Name: "client", Value: "users"}, {Name: "pool", Value: "primary"}}, Value: 2},
+		}, metric.Series)
+		return
+	}
+	t.Fatal("db_client_pool_max_open_connections not collected")
+}
+
+func TestRegisterDBStats_Unregister(t *testing.T) {
+	r := NewRegistry()
+	pool, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/orders")
+	assert.Nil(t, err)
+	defer pool.Close()
+	unregister := RegisterDBStats(r, pool, "orders", "primary")
+	unregister()
+	for _, metric := range r.Collect() {
+		assert.Empty(t, metric.Series, metric.Name)
+	}
+	RegisterDBStats(r, pool, "orders", "primary")
+	for _, metric := range r.Collect() {
+		assert.Equal(t, 1, len(metric.Series), metric.Name)
+	}
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WritePrometheus writes metrics in the Prometheus text exposition format.
+func WritePrometheus(w io.Writer, metrics []Metric) error {
+	bw := bufio.NewWriter(w)
+	for _, metric := range metrics {
+		if metric.Help != "" {
+			bw.WriteString("# HELP " + metric.Name + " " + escapeHelp(metric.Help) + "\n")
+		}
+		bw.WriteString("# TYPE " + metric.Name + " " + string(metric.Type) + "\n")
+		for _, s := range metric.Series {
+			if metric.Type != HistogramType {
+				writeSample(bw, metric.Name, s.Labels, s.Value)
+				continue
+			}
+			for _, bucket := range s.Buckets {
+				writeSample(bw, metric.Name+"_bucket", withLe(s.Labels, bucket.UpperBound), float64(bucket.Count))
+			}
+			writeSample(bw, metric.Name+"_bucket", withLe(s.Labels, math.Inf(1)), float64(s.Count))
+			writeSample(bw, metric.Name+"_sum", s.Labels, s.Sum)
+			writeSample(bw, metric.Name+"_count", s.Labels, float64(s.Count))
+		}
+	}
+	return bw.Flush()
+}
+
+// WritePrometheus collects the registry and writes it in the Prometheus
+// text exposition format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	return WritePrometheus(w, r.Collect())
+}
+
+// Handler serves the registry in the Prometheus text exposition format,
+// ready to be mounted on /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_ = r.WritePrometheus(w)
+	})
+}
+
+func withLe(labels []Label, upperBound float64) []Label {
+	return append(append([]Label(nil), labels...), Label{Name: "le", Value: formatFloat(upperBound)})
+}
+
+func writeSample(w *bufio.Writer, name string, labels []Label, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteString("{")
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString(label.Name + `="` + escapeLabelValue(label.Value) + `"`)
+		}
+		w.WriteString("}")
+	}
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	r.Gauge("connections", "Open connections.", "pool").Set(2, `a"b\c`)
+	r.Histogram("latency_seconds", "Latency\nin seconds.", []float64{0.1, 1}, "op").Observe(0.5, "find")
+	var buf bytes.Buffer
+	assert.Nil(t, r.WritePrometheus(&buf))
+	expected := `# HELP connections Open connections.
+# TYPE connections gauge
+connections{pool="a\"b\\c"} 2
+# HELP latency_seconds Latency\nin seconds.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="find",le="0.1"} 0
+latency_seconds_bucket{op="find",le="1"} 1
+latency_seconds_bucket{op="find",le="+Inf"} 1
+latency_seconds_sum{op="find"} 0.5
+latency_seconds_count{op="find"} 1
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("requests_total", "").Add(1)
+	recorder := httptest.NewRecorder()
+	r.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, contentType, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "# TYPE requests_total counter\nrequests_total 1\n", recorder.Body.String())
+}
@@ -0,0 +1,276 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Type string
+
+const (
+	CounterType   Type = "counter"
+	GaugeType     Type = "gauge"
+	HistogramType Type = "histogram"
+)
+
+// DefaultBuckets are the histogram upper bounds in seconds used when none
+// are given, from 1ms to 10s.
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry is an in-process set of metrics. Metrics are created once by
+// name; asking again for the same name returns the existing metric.
+type Registry struct {
+	mu         sync.Mutex
+	families   map[string]*family
+	collectors []collector
+	nextID     int
+}
+
+type collector struct {
+	id int
+	fn func()
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// family is a metric name with one series per combination of label values.
+type family struct {
+	mu         sync.Mutex
+	name       string
+	help       string
+	typ        Type
+	labelNames []string
+	buckets    []float64
+	series     map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	count       uint64
+	sum         float64
+	counts      []uint64
+}
+
+func (r *Registry) getFamily(name, help string, typ Type, buckets []float64, labelNames []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		if f.typ != typ || len(f.labelNames) != len(labelNames) {
+			panic(fmt.Sprintf("metrics: '%s' already registered as %s with labels %v", name, f.typ, f.labelNames))
+		}
+		return f
+	}
+	f := &family{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*series),
+	}
+	r.families[name] = f
+	return f
+}
+
+// deleteSeries drops the series of labelValues, it does nothing when there
+// is none.
+func (f *family) deleteSeries(labelValues []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.series, strings.Join(labelValues, "\xff"))
+}
+
+// getSeries must be called with f.mu held.
+func (f *family) getSeries(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metrics: '%s' expects labels %v, got values %v", f.name, f.labelNames, labelValues))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.typ == HistogramType {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+type Counter struct {
+	family *family
+}
+
+// Counter returns the counter called name, creating it if needed.
+func (r *Registry) Counter(name, help string, labelNames ...string) *Counter {
+	return &Counter{family: r.getFamily(name, help, CounterType, nil, labelNames)}
+}
+
+func (c *Counter) Add(value float64, labelValues ...string) {
+	c.family.mu.Lock()
+	defer c.family.mu.Unlock()
+	c.family.getSeries(labelValues).value += value
+}
+
+// Set overwrites the total, for counters mirroring a cumulative value kept
+// elsewhere such as sql.DBStats.WaitCount.
+func (c *Counter) Set(value float64, labelValues ...string) {
+	c.family.mu.Lock()
+	defer c.family.mu.Unlock()
+	c.family.getSeries(labelValues).value = value
+}
+
+// Delete drops the series of labelValues, for instance once what it counts
+// is gone.
+func (c *Counter) Delete(labelValues ...string) {
+	c.family.deleteSeries(labelValues)
+}
+
+type Gauge struct {
+	family *family
+}
+
+// Gauge returns the gauge called name, creating it if needed.
+func (r *Registry) Gauge(name, help string, labelNames ...string) *Gauge {
+	return &Gauge{family: r.getFamily(name, help, GaugeType, nil, labelNames)}
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.family.mu.Lock()
+	defer g.family.mu.Unlock()
+	g.family.getSeries(labelValues).value = value
+}
+
+// Delete drops the series of labelValues.
+func (g *Gauge) Delete(labelValues ...string) {
+	g.family.deleteSeries(labelValues)
+}
+
+type Histogram struct {
+	family *family
+}
+
+// Histogram returns the histogram called name, creating it if needed. The
+// buckets are sorted upper bounds, DefaultBuckets when nil.
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Histogram{family: r.getFamily(name, help, HistogramType, buckets, labelNames)}
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.family.mu.Lock()
+	defer h.family.mu.Unlock()
+	s := h.family.getSeries(labelValues)
+	s.count++
+	s.sum += value
+	for i, upperBound := range h.family.buckets {
+		if value <= upperBound {
+			s.counts[i]++
+		}
+	}
+}
+
+// RegisterCollector adds a function run at the start of every Collect, used
+// to refresh metrics that are read from somewhere else. The returned
+// function removes it.
+func (r *Registry) RegisterCollector(fn func()) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	id := r.nextID
+	r.collectors = append(r.collectors, collector{id: id, fn: fn})
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, c := range r.collectors {
+			if c.id == id {
+				r.collectors = append(r.collectors[:i:i], r.collectors[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Metric is the snapshot of one metric family.
+type Metric struct {
+	Name   string
+	Help   string
+	Type   Type
+	Series []Series
+}
+
+// Series is the snapshot of one set of label values. Value is set for
+// counters and gauges, Count, Sum and Buckets for histograms.
+type Series struct {
+	Labels  []Label
+	Value   float64
+	Count   uint64
+	Sum     float64
+	Buckets []Bucket
+}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Bucket counts the observations less than or equal to UpperBound.
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
+
+// Collect runs the registered collectors and returns a snapshot of every
+// metric, sorted by name and then by label values.
+func (r *Registry) Collect() []Metric {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+	for _, c := range collectors {
+		c.fn()
+	}
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+	metrics := make([]Metric, 0, len(families))
+	for _, f := range families {
+		metrics = append(metrics, f.collect())
+	}
+	return metrics
+}
+
+func (f *family) collect() Metric {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	metric := Metric{Name: f.name, Help: f.help, Type: f.typ}
+	for _, key := range keys {
+		s := f.series[key]
+		snapshot := Series{Value: s.value, Count: s.count, Sum: s.sum}
+		for i, name := range f.labelNames {
+			snapshot.Labels = append(snapshot.Labels, Label{Name: name, Value: s.labelValues[i]})
+		}
+		for i, upperBound := range f.buckets {
+			snapshot.Buckets = append(snapshot.Buckets, Bucket{UpperBound: upperBound, Count: s.counts[i]})
+		}
+		metric.Series = append(metric.Series, snapshot)
+	}
+	return metric
+}
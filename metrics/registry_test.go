@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRegistry_Collect(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("requests_total", "Requests.", "code").Add(2, "200")
+	r.Counter("requests_total", "Requests.", "code").Add(1, "200")
+	r.Gauge("connections", "Connections.").Set(3)
+	h := r.Histogram("latency_seconds", "Latency.", []float64{1, 0.1}, "op")
+	h.Observe(0.05, "find")
+	h.Observe(0.5, "find")
+	h.Observe(2, "find")
+	h.Observe(0.01, "count")
+	collected := 0
+	r.RegisterCollector(func() { collected++ })
+
+	unregister := r.RegisterCollector(func() { collected += 10 })
+	unregister()
+
+	metrics := r.Collect()
+	assert.Equal(t, 1, collected)
+	assert.Equal(t, 3, len(metrics))
+	assert.Equal(t, "connections", metrics[0].Name)
+	assert.Equal(t, GaugeType, metrics[0].Type)
+	assert.EqualValues(t, 3, metrics[0].Series[0].Value)
+
+	latency := metrics[1]
+	assert.Equal(t, "latency_seconds", latency.Name)
+	assert.Equal(t, 2, len(latency.Series))
+	assert.Equal(t, []Label{{Name: "op", Value: "count"}}, latency.Series[0].Labels)
+	find := latency.Series[1]
+	assert.EqualValues(t, 3, find.Count)
+	assert.InDelta(t, 2.55, find.Sum, 1e-9)
+	assert.Equal(t, []Bucket{{UpperBound: 0.1, Count: 1}, {UpperBound: 1, Count: 2}}, find.Buckets)
+
+	assert.Equal(t, CounterType, metrics[2].Type)
+	assert.EqualValues(t, 3, metrics[2].Series[0].Value)
+}
+
+func TestRegistry_Panics(t *testing.T) {
+	r := NewRegistry()
+	r.Gauge("connections", "Connections.", "pool")
+	assert.Panics(t, func() { r.Counter("connections", "Connections.", "pool") })
+	assert.Panics(t, func() { r.Gauge("connections", "Connections.", "pool").Set(1) })
+}
@@ -0,0 +1,50 @@
+package mysqlclient
+
+import (
+	"context"
+	"github.com/sillyhatxu/db-client/metrics"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMetricsOperation(t *testing.T) {
+	var testCases = []struct {
+		call     Call
+		expected string
+	}{
+		{call: Call{Op: OpExec, SQL: "INSERT INTO user (name) VALUES (?)"}, expected: "insert"},
+		{call: Call{Op: OpExec, SQL: " replace into user (name) values (?)"}, expected: "insert"},
+		{call: Call{Op: OpExec, SQL: "update user set name = ?"}, expected: "update"},
+		{call: Call{Op: OpExec, SQL: "DELETE FROM user"}, expected: "delete"},
+		{call: Call{Op: OpExec, SQL: "SET NAMES utf8mb4"}, expected: "exec"},
+		{call: Call{Op: OpExec}, expected: "exec"},
+		{call: Call{Op: OpQuery, SQL: "select * from user"}, expected: "find"},
+		{call: Call{Op: OpCount, SQL: "select count(1) from user"}, expected: "count"},
+		{call: Call{Op: OpTransaction}, expected: "tx"},
+		{call: Call{Op: OpTransaction, SQL: "SAVEPOINT sp_1"}, expected: "savepoint"},
+		{call: Call{Op: OpTransaction, SQL: "RELEASE SAVEPOINT sp_1"}, expected: "savepoint"},
+		{call: Call{Op: OpTransaction, SQL: "ROLLBACK TO SAVEPOINT sp_1"}, expected: "savepoint"},
+		{call: Call{Op: OpDDL, SQL: "CREATE TABLE t (id int)"}, expected: "ddl"},
+	}
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, metricsOperation(&test.call))
+	}
+}
+
+func TestMetricsInterceptor_SharedRegistry(t *testing.T) {
+	registry := metrics.NewRegistry()
+	orders := newMetricsInterceptor(registry, "orders")
+	users := newMetricsInterceptor(registry, "users")
+	next := func(ctx context.Context, call *Call) error { return nil }
+	assert.Nil(t, orders.Intercept(context.Background(), &Call{Op: OpQuery}, next))
+	assert.Nil(t, orders.Intercept(context.Background(), &Call{Op: OpQuery}, next))
+	assert.Nil(t, users.Intercept(context.Background(), &Call{Op: OpQuery}, next))
+	collected := registry.Collect()
+	assert.Equal(t, 1, len(collected))
+	assert.Equal(t, 2, len(collected[0].Series))
+	for i, client := range []string{"orders", "users"} {
+		series := collected[0].Series[i]
+		assert.Equal(t, metrics.Label{Name: "client", Value: client}, series.Labels[0])
+		assert.EqualValues(t, 2-i, series.Count)
+	}
+}
@@ -2,14 +2,31 @@ package mysqlclient
 
 import (
 	"database/sql"
+	"github.com/sillyhatxu/db-client/metrics"
 	"time"
 )
 
 type Config struct {
-	timeout time.Duration
-	pool    *sql.DB
-	ddlPath string
-	flyway  bool
+	timeout       time.Duration
+	pool          *sql.DB
+	ddlPath       string
+	flyway        bool
+	typedResult   bool
+	directScan    bool
+	txRetry       RetryPolicy
+	interceptors  []Interceptor
+	metrics       *metrics.Registry
+	metricsClient string
+	tracer        Tracer
+	stmtCache     int
+	maxPageSize   int
+	cursorSecret  []byte
+	killOnTimeout bool
+	healthCheck   *HealthCheckConfig
+
+	replicas             []*sql.DB
+	routing              RoutingPolicy
+	replicaCheckInterval time.Duration
 }
 
 type Option func(*Config)
@@ -37,3 +54,142 @@ func Timeout(timeout time.Duration) Option {
 		c.timeout = timeout
 	}
 }
+
+// TypedResult makes FindMapArray (and therefore Find) keep column types:
+// NULL becomes nil, integers int64/uint64, FLOAT/DOUBLE float64, DECIMAL a
+// string, DATE/DATETIME/TIMESTAMP time.Time and binary columns []byte.
+// The default is the legacy mode where every value is a string.
+func TypedResult(typedResult bool) Option {
+	return func(c *Config) {
+		c.typedResult = typedResult
+	}
+}
+
+// DirectScan controls the fast path of Find and FindFirst for struct
+// outputs, which scans columns straight into the struct fields instead of
+// decoding string maps. It is on by default.
+func DirectScan(directScan bool) Option {
+	return func(c *Config) {
+		c.directScan = directScan
+	}
+}
+
+// TxRetry sets the retry policy of every top level transaction, it can be
+// overridden per transaction with the Retry TxOption.
+func TxRetry(policy RetryPolicy) Option {
+	return func(c *Config) {
+		c.txRetry = policy
+	}
+}
+
+// Interceptors appends interceptors to the chain wrapping every statement,
+// transaction and flyway DDL of the client.
+func Interceptors(interceptors ...Interceptor) Option {
+	return func(c *Config) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// SlowQuery logs every statement, transaction and flyway DDL of the client
+// that takes at least config.Threshold.
+func SlowQuery(config SlowQueryConfig) Option {
+	return func(c *Config) {
+		c.interceptors = append(c.interceptors, newSlowQueryInterceptor(config))
+	}
+}
+
+// Metrics records the latency of every statement and transaction of the
+// client in registry, labelled by operation, and exports the stats of the
+// connection pool. Every series has a client label set to client, so that
+// clients sharing registry need different names.
+func Metrics(registry *metrics.Registry, client string) Option {
+	return func(c *Config) {
+		c.metrics = registry
+		c.metricsClient = client
+		c.interceptors = append(c.interceptors, newMetricsInterceptor(registry, client))
+	}
+}
+
+// Tracing starts a span of tracer for every statement, transaction and flyway
+// migration of the client.
+func Tracing(tracer Tracer) Option {
+	return func(c *Config) {
+		c.tracer = tracer
+		c.interceptors = append(c.interceptors, &tracingInterceptor{tracer: tracer})
+	}
+}
+
+// Replicas adds read replicas. Find, FindFirst, FindMapArray, Count,
+// FindCustom, QueryCursor and FindEach are served by a healthy replica,
+// writes and transactions by the primary pool.
+func Replicas(pools ...*sql.DB) Option {
+	return func(c *Config) {
+		c.replicas = append(c.replicas, pools...)
+	}
+}
+
+// Routing sets the policy choosing the replica of every read, RoundRobin by
+// default.
+func Routing(policy RoutingPolicy) Option {
+	return func(c *Config) {
+		c.routing = policy
+	}
+}
+
+// ReplicaCheckInterval sets how often replicas are pinged; a failing
+// replica is ejected until it answers again. The default is 5s.
+func ReplicaCheckInterval(interval time.Duration) Option {
+	return func(c *Config) {
+		c.replicaCheckInterval = interval
+	}
+}
+
+// StmtCache sets how many prepared statements are kept for reuse, 128 by
+// default. 0 prepares and closes a statement on every call, which is what
+// interpolateParams=true needs.
+func StmtCache(size int) Option {
+	return func(c *Config) {
+		c.stmtCache = size
+	}
+}
+
+// MaxPageSize caps the page size of FindPage and the limit of FindKeyset,
+// 1000 by default. 0 or less removes the cap.
+func MaxPageSize(maxPageSize int) Option {
+	return func(c *Config) {
+		c.maxPageSize = maxPageSize
+	}
+}
+
+// CursorSecret sets the key signing the cursor tokens of FindKeyset. Without
+// it a random key is used, so tokens are only valid within the process that
+// issued them.
+func CursorSecret(secret []byte) Option {
+	return func(c *Config) {
+		c.cursorSecret = secret
+	}
+}
+
+// KillOnTimeout runs every statement outside transactions on a connection
+// whose CONNECTION_ID() is read first, and runs KILL QUERY on another
+// connection when the context is done before the statement ends, as the
+// server would go on otherwise. The error is then a *KillError telling
+// whether the kill succeeded. It costs a round trip per statement and
+// disables the statement cache. One connection of every pool is reserved
+// for the kills, so NewMysqlClient rejects a pool with a MaxOpenConns of 1.
+// Statements run in a transaction are not killed, cancelling the context
+// only makes the driver drop the connection while the server goes on.
+func KillOnTimeout(killOnTimeout bool) Option {
+	return func(c *Config) {
+		c.killOnTimeout = killOnTimeout
+	}
+}
+
+// HealthCheck pings the primary pool every config.Interval in the background
+// to keep the status returned by Health and call the HealthFuncs on state
+// changes.
+func HealthCheck(config HealthCheckConfig) Option {
+	return func(c *Config) {
+		c.healthCheck = &config
+	}
+}
@@ -0,0 +1,123 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"github.com/sillyhatxu/db-client/builder"
+)
+
+const (
+	defaultPageSize    = 20
+	defaultMaxPageSize = 1000
+)
+
+// pageSize applies the default page size and the MaxPageSize cap to size.
+func (c *Config) pageSize(size int) int {
+	if size < 1 {
+		size = defaultPageSize
+	}
+	if c.maxPageSize > 0 && size > c.maxPageSize {
+		size = c.maxPageSize
+	}
+	return size
+}
+
+type Page struct {
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+type pageConfig struct {
+	snapshot bool
+}
+
+type PageOption func(*pageConfig)
+
+// ConsistentSnapshot runs the count and the select of FindPage in one read
+// only REPEATABLE READ transaction, so that both see the same data. It also
+// makes them go to the primary.
+func ConsistentSnapshot(snapshot bool) PageOption {
+	return func(c *pageConfig) {
+		c.snapshot = snapshot
+	}
+}
+
+func (mc *MysqlClient) FindPage(table string, where map[string]interface{}, page, pageSize int, output interface{}, opts ...PageOption) (Page, error) {
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.FindPageContext(ctx, table, where, page, pageSize, output, opts...)
+}
+
+// FindPageContext reads page (1-based) of the rows selected by where into
+// output and counts them all. where is taken as by builder.BuildSelect, its
+// _limit is replaced by the page and _orderby should make the order stable.
+// pageSize is capped by the MaxPageSize of the client, 20 is used when it is
+// not positive. Pages past the end leave output untouched.
+func (mc *MysqlClient) FindPageContext(ctx context.Context, table string, where map[string]interface{}, page, pageSize int, output interface{}, opts ...PageOption) (Page, error) {
+	config := &pageConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if page < 1 {
+		page = 1
+	}
+	pageSize = mc.config.pageSize(pageSize)
+	result := Page{Page: page, PageSize: pageSize}
+	countSQL, countArgs, err := buildPageCount(table, where)
+	if err != nil {
+		return result, err
+	}
+	offset := uint(page-1) * uint(pageSize)
+	selectWhere := copyMap(where)
+	selectWhere["_limit"] = []uint{offset, uint(pageSize)}
+	selectSQL, selectArgs, err := builder.BuildSelect(table, selectWhere, nil)
+	if err != nil {
+		return result, err
+	}
+	run := func(ctx context.Context, session Session) error {
+		total, err := session.CountContext(ctx, countSQL, countArgs...)
+		if err != nil {
+			return err
+		}
+		result.Total = total
+		result.TotalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+		if int64(offset) >= total {
+			return nil
+		}
+		return session.FindContext(ctx, selectSQL, output, selectArgs...)
+	}
+	if !config.snapshot {
+		return result, run(ctx, mc)
+	}
+	err = mc.WithTxContext(ctx, func(ctx context.Context, tx *Tx) error {
+		return run(ctx, tx)
+	}, ReadOnly(true), Isolation(sql.LevelRepeatableRead))
+	return result, err
+}
+
+// buildPageCount counts what where selects, without its _orderby, _limit
+// and _lockMode. With a _groupby the groups are counted.
+func buildPageCount(table string, where map[string]interface{}) (string, []interface{}, error) {
+	countWhere := copyMap(where)
+	delete(countWhere, "_orderby")
+	delete(countWhere, "_limit")
+	delete(countWhere, "_lockMode")
+	if _, ok := countWhere["_groupby"]; !ok {
+		return builder.BuildSelect(table, countWhere, []string{"count(1)"})
+	}
+	query, args, err := builder.BuildSelect(table, countWhere, []string{"1"})
+	if err != nil {
+		return "", nil, err
+	}
+	return "SELECT count(1) FROM (" + query + ") t", args, nil
+}
+
+func copyMap(src map[string]interface{}) map[string]interface{} {
+	target := make(map[string]interface{}, len(src)+1)
+	for k, v := range src {
+		target[k] = v
+	}
+	return target
+}
@@ -0,0 +1,52 @@
+package mysqlclient
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBuildPageCount(t *testing.T) {
+	var testCases = []struct {
+		where map[string]interface{}
+		sql   string
+		args  []interface{}
+	}{
+		{where: nil, sql: "SELECT count(1) FROM user"},
+		{
+			where: map[string]interface{}{"status": true, "_orderby": "id DESC", "_limit": []uint{20, 10}, "_lockMode": "share"},
+			sql:   "SELECT count(1) FROM user WHERE (status=?)",
+			args:  []interface{}{true},
+		},
+		{
+			where: map[string]interface{}{"status": true, "_groupby": "platform", "_having": map[string]interface{}{"count(1) >": 1}},
+			sql:   "SELECT count(1) FROM (SELECT 1 FROM user WHERE (status=?) GROUP BY platform HAVING (count(1)>?)) t",
+			args:  []interface{}{true, 1},
+		},
+	}
+	for _, test := range testCases {
+		sql, args, err := buildPageCount("user", test.where)
+		assert.Nil(t, err)
+		assert.Equal(t, test.sql, sql)
+		assert.EqualValues(t, test.args, args)
+	}
+}
+
+func TestConfig_PageSize(t *testing.T) {
+	var testCases = []struct {
+		maxPageSize int
+		size        int
+		expected    int
+	}{
+		{maxPageSize: defaultMaxPageSize, size: 0, expected: defaultPageSize},
+		{maxPageSize: defaultMaxPageSize, size: 50, expected: 50},
+		{maxPageSize: defaultMaxPageSize, size: 5000, expected: defaultMaxPageSize},
+		{maxPageSize: 0, size: 5000, expected: 5000},
+		{maxPageSize: -1, size: 5000, expected: 5000},
+		{maxPageSize: -1, size: -1, expected: defaultPageSize},
+	}
+	for _, test := range testCases {
+		config := &Config{}
+		MaxPageSize(test.maxPageSize)(config)
+		assert.Equal(t, test.expected, config.pageSize(test.size))
+	}
+}
@@ -0,0 +1,173 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/sillyhatxu/db-client/dbclient"
+	"sort"
+	"sync"
+)
+
+// ClientConfig declares a client of a Registry.
+type ClientConfig struct {
+	UserName string
+	Password string
+	Host     string
+	Port     int
+	Schema   string
+	// DBOptions are applied to the pool after the fields above.
+	DBOptions []dbclient.Option
+	// Pool, when set, is used instead of opening one from the fields above.
+	// It is closed with the client.
+	Pool *sql.DB
+	// Options configure the client. Flyway and DDLPath below, when not nil,
+	// take precedence over the ones they contain, false and "" included.
+	Options []Option
+	Flyway  *bool
+	DDLPath *string
+	// Lazy defers opening the client to the first Get of its name.
+	Lazy bool
+}
+
+func (c ClientConfig) dbOptions() []dbclient.Option {
+	opts := []dbclient.Option{
+		dbclient.UserName(c.UserName),
+		dbclient.Password(c.Password),
+		dbclient.Host(c.Host),
+		dbclient.Port(c.Port),
+		dbclient.Schema(c.Schema),
+	}
+	return append(opts, c.DBOptions...)
+}
+
+func (c ClientConfig) options(pool *sql.DB) []Option {
+	opts := append([]Option{Pool(pool)}, c.Options...)
+	if c.Flyway != nil {
+		opts = append(opts, Flyway(*c.Flyway))
+	}
+	if c.DDLPath != nil {
+		opts = append(opts, DDLPath(*c.DDLPath))
+	}
+	return opts
+}
+
+type registryEntry struct {
+	mu     sync.Mutex
+	config ClientConfig
+	client *MysqlClient
+}
+
+// Registry holds the clients of a service by name.
+type Registry struct {
+	mu      sync.Mutex
+	entries map[string]*registryEntry
+	closed  bool
+}
+
+// NewRegistry opens the clients of configs that are not Lazy. It fails with
+// the first client that can't be opened, after closing the others.
+func NewRegistry(configs map[string]ClientConfig) (*Registry, error) {
+	r := &Registry{entries: make(map[string]*registryEntry, len(configs))}
+	for name, config := range configs {
+		r.entries[name] = &registryEntry{config: config}
+	}
+	for _, name := range r.Names() {
+		if r.entries[name].config.Lazy {
+			continue
+		}
+		if _, err := r.Get(name); err != nil {
+			_ = r.CloseAll(context.Background())
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Names returns the names of the clients, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the client of name, opening it if it is Lazy and not opened
+// yet. A failed open is tried again by the next Get.
+func (r *Registry) Get(name string) (*MysqlClient, error) {
+	entry, ok := r.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("no client named %s", name)
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	r.mu.Lock()
+	closed := r.closed
+	r.mu.Unlock()
+	if closed {
+		return nil, ErrClientClosed
+	}
+	if entry.client != nil {
+		return entry.client, nil
+	}
+	client, err := entry.open()
+	if err != nil {
+		return nil, fmt.Errorf("open client %s: %w", name, err)
+	}
+	entry.client = client
+	return client, nil
+}
+
+func (e *registryEntry) open() (*MysqlClient, error) {
+	pool := e.config.Pool
+	if pool == nil {
+		var err error
+		pool, err = dbclient.NewDBClient(e.config.dbOptions()...)
+		if err != nil {
+			if pool != nil {
+				_ = pool.Close()
+			}
+			return nil, err
+		}
+	}
+	client, err := NewMysqlClient(e.config.options(pool)...)
+	if err != nil && e.config.Pool == nil {
+		_ = pool.Close()
+	}
+	return client, err
+}
+
+// CloseAll closes the opened clients, see MysqlClient.Close, and makes Get
+// fail with ErrClientClosed from then on. It returns the first error.
+func (r *Registry) CloseAll(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	var wg sync.WaitGroup
+	errs := make([]error, len(r.entries))
+	for i, name := range r.Names() {
+		entry := r.entries[name]
+		entry.mu.Lock()
+		client := entry.client
+		entry.mu.Unlock()
+		if client == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			if err := client.Close(ctx); err != nil && err != ErrClientClosed {
+				errs[i] = fmt.Errorf("close client %s: %w", name, err)
+			}
+		}(i, name)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package mysqlclient
+
+import (
+	"context"
+	"github.com/sillyhatxu/db-client/dbclient"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRegistryLazy(t *testing.T) {
+	unreachable := ClientConfig{
+		Host:      "127.0.0.1",
+		Port:      1,
+		DBOptions: []dbclient.Option{dbclient.Timeout(time.Second)},
+		Lazy:      true,
+	}
+	registry, err := NewRegistry(map[string]ClientConfig{"orders": unreachable, "users": unreachable})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"orders", "users"}, registry.Names())
+
+	_, err = registry.Get("payments")
+	assert.EqualError(t, err, "no client named payments")
+	_, err = registry.Get("orders")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, registry.CloseAll(context.Background()))
+	_, err = registry.Get("users")
+	assert.Equal(t, ErrClientClosed, err)
+}
+
+func TestRegistryEager(t *testing.T) {
+	_, err := NewRegistry(map[string]ClientConfig{
+		"orders": {Host: "127.0.0.1", Port: 1, DBOptions: []dbclient.Option{dbclient.Timeout(time.Second)}},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestClientConfigOptions(t *testing.T) {
+	on, off, b, empty := true, false, "b", ""
+	var testCases = []struct {
+		config  ClientConfig
+		flyway  bool
+		ddlPath string
+	}{
+		{config: ClientConfig{Options: []Option{Flyway(true), DDLPath("a")}}, flyway: true, ddlPath: "a"},
+		{config: ClientConfig{Options: []Option{DDLPath("a")}, Flyway: &on, DDLPath: &b}, flyway: true, ddlPath: "b"},
+		{config: ClientConfig{Options: []Option{Flyway(true), DDLPath("a")}, Flyway: &off, DDLPath: &empty}, flyway: false, ddlPath: ""},
+		{config: ClientConfig{}, flyway: false, ddlPath: ""},
+	}
+	for _, test := range testCases {
+		c := &Config{}
+		for _, opt := range append(test.config.options(nil), Timeout(time.Second)) {
+			opt(c)
+		}
+		assert.Equal(t, test.flyway, c.flyway)
+		assert.Equal(t, test.ddlPath, c.ddlPath)
+		assert.Equal(t, time.Second, c.timeout)
+	}
+}
+
+func TestRegistry_CloseAll(t *testing.T) {
+	registry, err := NewRegistry(map[string]ClientConfig{
+		schema: {UserName: userName, Password: password, Host: host, Port: port, Schema: schema},
+	})
+	assert.Nil(t, err)
+	client, err := registry.Get(schema)
+	assert.Nil(t, err)
+	assert.Nil(t, client.Ping())
+	assert.Nil(t, registry.CloseAll(context.Background()))
+	client, err = registry.Get(schema)
+	assert.Nil(t, client)
+	assert.Equal(t, ErrClientClosed, err)
+}
@@ -0,0 +1,131 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+const defaultReplicaCheckInterval = 5 * time.Second
+
+// RoutingPolicy selects the replica serving a read.
+type RoutingPolicy int
+
+const (
+	RoundRobin RoutingPolicy = iota
+	Random
+	// LeastInUse picks the replica with the fewest connections in use.
+	LeastInUse
+)
+
+type replica struct {
+	pool    *sql.DB
+	healthy int32
+}
+
+func (r *replica) isHealthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+// setHealthy reports whether the state changed.
+func (r *replica) setHealthy(healthy bool) bool {
+	var value int32
+	if healthy {
+		value = 1
+	}
+	return atomic.SwapInt32(&r.healthy, value) != value
+}
+
+type replicaSet struct {
+	replicas []*replica
+	policy   RoutingPolicy
+	next     uint64
+}
+
+func newReplicaSet(pools []*sql.DB, policy RoutingPolicy) *replicaSet {
+	set := &replicaSet{policy: policy}
+	for _, pool := range pools {
+		set.replicas = append(set.replicas, &replica{pool: pool, healthy: 1})
+	}
+	return set
+}
+
+// pick returns a healthy replica, or nil when there is none.
+func (s *replicaSet) pick() *sql.DB {
+	healthy := make([]*replica, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		if r.isHealthy() {
+			healthy = append(healthy, r)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	switch s.policy {
+	case Random:
+		jitterMu.Lock()
+		defer jitterMu.Unlock()
+		return healthy[jitterRand.Intn(len(healthy))].pool
+	case LeastInUse:
+		least := healthy[0].pool
+		inUse := least.Stats().InUse
+		for _, r := range healthy[1:] {
+			if n := r.pool.Stats().InUse; n < inUse {
+				least, inUse = r.pool, n
+			}
+		}
+		return least
+	}
+	n := atomic.AddUint64(&s.next, 1) - 1
+	return healthy[n%uint64(len(healthy))].pool
+}
+
+type forcePrimaryKey struct{}
+
+// ForcePrimary returns a context whose reads go to the primary, for reading
+// back what was just written. Reads made with the context of a transaction
+// go to the primary as well.
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey{}, true)
+}
+
+func isPrimaryForced(ctx context.Context) bool {
+	if forced, _ := ctx.Value(forcePrimaryKey{}).(bool); forced {
+		return true
+	}
+	_, inTx := TxFromContext(ctx)
+	return inTx
+}
+
+// readExecutor returns the executor of a read, on a replica unless the
+// primary is forced or no replica is healthy.
+func (mc *MysqlClient) readExecutor(ctx context.Context) *executor {
+	if mc.replicas == nil || isPrimaryForced(ctx) {
+		return mc.executor()
+	}
+	pool := mc.replicas.pick()
+	if pool == nil {
+		return mc.executor()
+	}
+	return mc.newExecutor(pool, pool)
+}
+
+// checkReplicas pings every replica, ejecting the failing ones from the
+// routing and bringing back the ones that recovered.
+func (mc *MysqlClient) checkReplicas() {
+	for i, r := range mc.replicas.replicas {
+		ctx, cancel := mc.getContext()
+		err := r.pool.PingContext(ctx)
+		cancel()
+		if !r.setHealthy(err == nil) {
+			continue
+		}
+		if err != nil {
+			log.Printf("replica %d ejected: %v", i, wrapError(err))
+		} else {
+			log.Printf("replica %d is back", i)
+		}
+	}
+}
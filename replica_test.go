@@ -0,0 +1,49 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func openReplicas(t *testing.T, n int) []*sql.DB {
+	var pools []*sql.DB
+	for i := 0; i < n; i++ {
+		pool, err := sql.Open("mysql", "replica:replica@tcp(127.0.0.1:3306)/test")
+		assert.Nil(t, err)
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+func TestReplicaSet_Pick(t *testing.T) {
+	pools := openReplicas(t, 3)
+	set := newReplicaSet(pools, RoundRobin)
+	assert.Equal(t, pools[0], set.pick())
+	assert.Equal(t, pools[1], set.pick())
+	assert.Equal(t, pools[2], set.pick())
+	assert.Equal(t, pools[0], set.pick())
+
+	set.replicas[1].setHealthy(false)
+	for i := 0; i < 6; i++ {
+		assert.NotEqual(t, pools[1], set.pick())
+	}
+	set.replicas[0].setHealthy(false)
+	set.replicas[2].setHealthy(false)
+	assert.Nil(t, set.pick())
+
+	assert.True(t, set.replicas[1].setHealthy(true))
+	assert.False(t, set.replicas[1].setHealthy(true))
+	set.policy = Random
+	assert.Equal(t, pools[1], set.pick())
+	set.policy = LeastInUse
+	assert.Equal(t, pools[1], set.pick())
+}
+
+func TestIsPrimaryForced(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, isPrimaryForced(ctx))
+	assert.True(t, isPrimaryForced(ForcePrimary(ctx)))
+	assert.True(t, isPrimaryForced(context.WithValue(ctx, txContextKey{}, &Tx{})))
+}
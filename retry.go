@@ -0,0 +1,89 @@
+package mysqlclient
+
+import (
+	"context"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = 20 * time.Millisecond
+	defaultRetryMaxBackoff = time.Second
+)
+
+// RetryPolicy re-runs a whole transaction when it fails with a deadlock
+// (1213) or a lock wait timeout (1205).
+type RetryPolicy struct {
+	// Attempts is the total number of runs including the first one.
+	Attempts int
+	// Backoff is the delay before the first retry, doubled on every retry
+	// up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter is the fraction (0-1) of every delay that is randomized.
+	Jitter float64
+}
+
+var (
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	jitterMu   sync.Mutex
+)
+
+func isRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == errNumberDeadlock || mysqlErr.Number == errNumberLockWaitTimeout
+}
+
+func (p RetryPolicy) delay(retry int) time.Duration {
+	backoff, maxBackoff := p.Backoff, p.MaxBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	delay := backoff
+	for i := 1; i < retry && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		jitterMu.Lock()
+		r := jitterRand.Float64()
+		jitterMu.Unlock()
+		delay = time.Duration(float64(delay) * (1 - jitter + r*jitter))
+	}
+	return delay
+}
+
+// run calls fn until it succeeds, fails with a non-retryable error, runs
+// out of attempts or ctx is done. It returns the number of runs made.
+func (p RetryPolicy) run(ctx context.Context, fn func() error) (int, error) {
+	attempt := 1
+	for {
+		err := fn()
+		if err == nil || attempt >= p.Attempts || !isRetryable(err) {
+			return attempt, err
+		}
+		timer := time.NewTimer(p.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, err
+		case <-timer.C:
+		}
+		attempt++
+	}
+}
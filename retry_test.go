@@ -0,0 +1,55 @@
+package mysqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(&mysql.MySQLError{Number: 1213}))
+	assert.True(t, isRetryable(fmt.Errorf("update user: %w", &mysql.MySQLError{Number: 1205})))
+	assert.False(t, isRetryable(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, isRetryable(errors.New("deadlock")))
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{Backoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	assert.EqualValues(t, 10*time.Millisecond, policy.delay(1))
+	assert.EqualValues(t, 20*time.Millisecond, policy.delay(2))
+	assert.EqualValues(t, 40*time.Millisecond, policy.delay(3))
+	assert.EqualValues(t, 50*time.Millisecond, policy.delay(4))
+	policy.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		delay := policy.delay(2)
+		assert.True(t, delay >= 10*time.Millisecond && delay <= 20*time.Millisecond)
+	}
+}
+
+func TestRetryPolicy_Run(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+	calls := 0
+	attempts, err := policy.run(context.Background(), func() error {
+		calls++
+		return &mysql.MySQLError{Number: 1213}
+	})
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 3, attempts)
+	assert.EqualValues(t, 3, calls)
+
+	attempts, err = policy.run(context.Background(), func() error {
+		return &mysql.MySQLError{Number: 1062}
+	})
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 1, attempts)
+
+	attempts, err = RetryPolicy{}.run(context.Background(), func() error {
+		return &mysql.MySQLError{Number: 1213}
+	})
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 1, attempts)
+}
@@ -0,0 +1,197 @@
+package mysqlclient
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+type columnKind int
+
+const (
+	stringColumn columnKind = iota
+	intColumn
+	uintColumn
+	floatColumn
+	decimalColumn
+	bytesColumn
+	timeColumn
+)
+
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func getColumnKind(columnType *sql.ColumnType) columnKind {
+	switch columnType.DatabaseTypeName() {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR":
+		//the driver only reports unsigned scan types for NOT NULL columns,
+		//nullable unsigned values are caught by the overflow check in convertColumn
+		if scanType := columnType.ScanType(); scanType != nil {
+			switch scanType.Kind() {
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				return uintColumn
+			}
+		}
+		return intColumn
+	case "FLOAT", "DOUBLE":
+		return floatColumn
+	case "DECIMAL":
+		return decimalColumn
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return timeColumn
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "BIT", "GEOMETRY":
+		return bytesColumn
+	default:
+		return stringColumn
+	}
+}
+
+// convertColumn turns a raw driver value into the Go type matching the
+// column: nil, int64, uint64, float64, []byte, time.Time or string.
+func convertColumn(name string, kind columnKind, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch kind {
+	case intColumn, uintColumn:
+		switch v := value.(type) {
+		case int64:
+			if kind == uintColumn && v >= 0 {
+				return uint64(v), nil
+			}
+			return v, nil
+		case uint64:
+			return v, nil
+		case []byte:
+			return parseInteger(name, kind, string(v))
+		}
+	case floatColumn:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case []byte:
+			f, err := strconv.ParseFloat(string(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse column '%s' as float: %s", name, err)
+			}
+			return f, nil
+		}
+	case decimalColumn:
+		if v, ok := value.([]byte); ok {
+			return string(v), nil
+		}
+	case timeColumn:
+		if v, ok := value.([]byte); ok {
+			return parseTime(string(v)), nil
+		}
+	case bytesColumn:
+		return value, nil
+	case stringColumn:
+		if v, ok := value.([]byte); ok {
+			return string(v), nil
+		}
+	}
+	return value, nil
+}
+
+func parseInteger(name string, kind columnKind, s string) (interface{}, error) {
+	if kind == intColumn {
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err == nil {
+			return i, nil
+		}
+		if numErr, ok := err.(*strconv.NumError); !ok || numErr.Err != strconv.ErrRange {
+			return nil, fmt.Errorf("cannot parse column '%s' as int: %s", name, err)
+		}
+	}
+	u, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse column '%s' as uint: %s", name, err)
+	}
+	return u, nil
+}
+
+// parseTime is only reached when the DSN has parseTime=false
+func parseTime(s string) interface{} {
+	if len(s) >= 10 && s[:10] == "0000-00-00" {
+		return time.Time{}
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return s
+}
+
+// mapScanner reads rows into maps keyed by column name. In typed mode the
+// values keep their column types, otherwise every value is a string.
+type mapScanner struct {
+	columns []string
+	kinds   []columnKind
+	bytes   [][]byte
+	values  []interface{}
+	scans   []interface{}
+}
+
+func newMapScanner(rows *sql.Rows, typed bool) (*mapScanner, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	s := &mapScanner{
+		columns: columns,
+		//query.Scan的参数，因为每次查询出来的列是不定长的，用len(cols)定住当次查询的长度
+		scans: make([]interface{}, len(columns)),
+	}
+	if !typed {
+		//values是每个列的值，这里获取到byte里
+		s.bytes = make([][]byte, len(columns))
+		//让每一行数据都填充到[][]byte里面
+		for i := range s.bytes {
+			s.scans[i] = &s.bytes[i]
+		}
+		return s, nil
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	s.kinds = make([]columnKind, len(columns))
+	for i, columnType := range columnTypes {
+		s.kinds[i] = getColumnKind(columnType)
+	}
+	s.values = make([]interface{}, len(columns))
+	for i := range s.values {
+		s.scans[i] = &s.values[i]
+	}
+	return s, nil
+}
+
+func (s *mapScanner) scan(rows *sql.Rows) (map[string]interface{}, error) {
+	//query.Scan查询出来的不定长值放到scans[i],也就是每行都放在values里
+	if err := rows.Scan(s.scans...); err != nil {
+		return nil, err
+	}
+	row := make(map[string]interface{}, len(s.columns))
+	if s.kinds == nil {
+		for k, v := range s.bytes {
+			row[s.columns[k]] = string(v)
+		}
+		return row, nil
+	}
+	for k, v := range s.values {
+		value, err := convertColumn(s.columns[k], s.kinds[k], v)
+		if err != nil {
+			return nil, err
+		}
+		row[s.columns[k]] = value
+	}
+	return row, nil
+}
@@ -0,0 +1,37 @@
+package mysqlclient
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestConvertColumn(t *testing.T) {
+	var data = []struct {
+		kind  columnKind
+		input interface{}
+		out   interface{}
+	}{
+		{kind: intColumn, input: nil, out: nil},
+		{kind: intColumn, input: []byte("-12"), out: int64(-12)},
+		{kind: intColumn, input: int64(7), out: int64(7)},
+		{kind: intColumn, input: []byte("18446744073709551615"), out: uint64(18446744073709551615)},
+		{kind: uintColumn, input: int64(7), out: uint64(7)},
+		{kind: uintColumn, input: []byte("7"), out: uint64(7)},
+		{kind: floatColumn, input: []byte("1.5"), out: float64(1.5)},
+		{kind: floatColumn, input: float32(2), out: float64(2)},
+		{kind: decimalColumn, input: []byte("354.25"), out: "354.25"},
+		{kind: bytesColumn, input: []byte{0, 1, 255}, out: []byte{0, 1, 255}},
+		{kind: stringColumn, input: []byte("name"), out: "name"},
+		{kind: timeColumn, input: []byte("2020-01-02 03:04:05"), out: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{kind: timeColumn, input: []byte("2020-01-02"), out: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{kind: timeColumn, input: []byte("0000-00-00 00:00:00"), out: time.Time{}},
+	}
+	for _, d := range data {
+		out, err := convertColumn("column", d.kind, d.input)
+		assert.Nil(t, err)
+		assert.Equal(t, d.out, out)
+	}
+	_, err := convertColumn("column", intColumn, []byte("abc"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,37 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Session is the query and DML surface shared by MysqlClient and Tx, so
+// repository code can run either inside or outside a transaction.
+type Session interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Insert(query string, args ...interface{}) (int64, error)
+	InsertContext(ctx context.Context, query string, args ...interface{}) (int64, error)
+	Update(query string, args ...interface{}) (int64, error)
+	UpdateContext(ctx context.Context, query string, args ...interface{}) (int64, error)
+	Delete(query string, args ...interface{}) (int64, error)
+	DeleteContext(ctx context.Context, query string, args ...interface{}) (int64, error)
+	Count(query string, args ...interface{}) (int64, error)
+	CountContext(ctx context.Context, query string, args ...interface{}) (int64, error)
+	FindCustom(query string, fieldFunc FieldFunc, args ...interface{}) error
+	FindCustomContext(ctx context.Context, query string, fieldFunc FieldFunc, args ...interface{}) error
+	Find(query string, output interface{}, args ...interface{}) error
+	FindContext(ctx context.Context, query string, output interface{}, args ...interface{}) error
+	FindFirst(query string, output interface{}, args ...interface{}) error
+	FindFirstContext(ctx context.Context, query string, output interface{}, args ...interface{}) error
+	FindMapArray(query string, args ...interface{}) ([]map[string]interface{}, error)
+	FindMapArrayContext(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error)
+	QueryCursor(ctx context.Context, query string, args ...interface{}) (*Cursor, error)
+	FindEach(query string, rowFunc RowFunc, args ...interface{}) error
+	FindEachContext(ctx context.Context, query string, rowFunc RowFunc, args ...interface{}) error
+}
+
+var (
+	_ Session = (*MysqlClient)(nil)
+	_ Session = (*Tx)(nil)
+)
@@ -1,7 +1,6 @@
 package mysqlclient
 
 import (
-	"github.com/sillyhatxu/db-client/dbclient"
 	"sync"
 	"time"
 )
@@ -18,17 +17,20 @@ var mysqlClient *MysqlClient
 var once sync.Once
 
 func setup() {
-	mc, err := dbclient.NewDBClient(
-		dbclient.UserName(userName),
-		dbclient.Password(password),
-		dbclient.Host(host),
-		dbclient.Port(port),
-		dbclient.Schema(schema),
-	)
+	registry, err := NewRegistry(map[string]ClientConfig{
+		schema: {
+			UserName: userName,
+			Password: password,
+			Host:     host,
+			Port:     port,
+			Schema:   schema,
+			Options:  []Option{Timeout(20 * time.Second)},
+		},
+	})
 	if err != nil {
 		panic(err)
 	}
-	mysqlClient, err = NewMysqlClient(Pool(mc), Timeout(20*time.Second))
+	mysqlClient, err = registry.Get(schema)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,154 @@
+package mysqlclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// SlowQueryConfig configures the slow query log installed by the SlowQuery
+// option.
+type SlowQueryConfig struct {
+	// Threshold is the minimum duration of a logged call, 0 logs every call.
+	Threshold time.Duration
+	// Logger receives the slow calls, the default writes them with log.Printf.
+	Logger SlowQueryLogger
+	// ArgSampleRate is the fraction (0-1) of slow calls whose args are
+	// recorded; the others are logged with nil Args.
+	ArgSampleRate float64
+	// RedactArgs records the type of every arg instead of its value.
+	RedactArgs bool
+	// MaxArgLength truncates string and []byte args longer than it, 0 keeps
+	// them whole.
+	MaxArgLength int
+}
+
+// SlowQueryEntry is one call that took at least the configured threshold.
+type SlowQueryEntry struct {
+	Op       Operation
+	SQL      string
+	Args     []interface{}
+	Duration time.Duration
+	// Caller is the file:line of the first frame outside this package.
+	Caller string
+	// Rows is the number of rows affected or returned, -1 when unknown.
+	Rows int64
+	Err  error
+}
+
+type SlowQueryLogger func(query *SlowQueryEntry)
+
+func (q *SlowQueryEntry) String() string {
+	s := fmt.Sprintf("slow %s (%v) at %s", q.Op, q.Duration, q.Caller)
+	if q.SQL != "" {
+		s += ": " + q.SQL
+	}
+	if q.Args != nil {
+		s += fmt.Sprintf(" %v", q.Args)
+	}
+	s += fmt.Sprintf("; rows: %d", q.Rows)
+	if q.Err != nil {
+		s += fmt.Sprintf("; error: %v", q.Err)
+	}
+	return s
+}
+
+func defaultSlowQueryLogger(query *SlowQueryEntry) {
+	log.Printf("%s", query)
+}
+
+type slowQueryInterceptor struct {
+	config SlowQueryConfig
+}
+
+func newSlowQueryInterceptor(config SlowQueryConfig) *slowQueryInterceptor {
+	if config.Logger == nil {
+		config.Logger = defaultSlowQueryLogger
+	}
+	return &slowQueryInterceptor{config: config}
+}
+
+func (i *slowQueryInterceptor) Intercept(ctx context.Context, call *Call, next Handler) error {
+	err := next(ctx, call)
+	if call.Duration < i.config.Threshold {
+		return err
+	}
+	i.config.Logger(&SlowQueryEntry{
+		Op:       call.Op,
+		SQL:      call.SQL,
+		Args:     i.captureArgs(call.Args),
+		Duration: call.Duration,
+		Caller:   caller(),
+		Rows:     call.Rows,
+		Err:      err,
+	})
+	return err
+}
+
+func (i *slowQueryInterceptor) captureArgs(args []interface{}) []interface{} {
+	if len(args) == 0 || !i.sampled() {
+		return nil
+	}
+	captured := make([]interface{}, len(args))
+	for n, arg := range args {
+		switch {
+		case i.config.RedactArgs:
+			captured[n] = fmt.Sprintf("<%T>", arg)
+		case i.config.MaxArgLength > 0:
+			captured[n] = truncateArg(arg, i.config.MaxArgLength)
+		default:
+			captured[n] = arg
+		}
+	}
+	return captured
+}
+
+func (i *slowQueryInterceptor) sampled() bool {
+	if i.config.ArgSampleRate <= 0 {
+		return false
+	}
+	if i.config.ArgSampleRate >= 1 {
+		return true
+	}
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return jitterRand.Float64() < i.config.ArgSampleRate
+}
+
+func truncateArg(arg interface{}, maxLength int) interface{} {
+	switch v := arg.(type) {
+	case string:
+		if len(v) > maxLength {
+			return v[:maxLength] + "..."
+		}
+	case []byte:
+		if len(v) > maxLength {
+			return string(v[:maxLength]) + "..."
+		}
+	}
+	return arg
+}
+
+var packagePath = reflect.TypeOf(Call{}).PkgPath()
+
+// caller returns the file:line of the first frame outside this package,
+// frames of the package tests count as outside.
+func caller() string {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		inPackage := strings.HasPrefix(frame.Function, packagePath+".") && !strings.HasSuffix(frame.File, "_test.go")
+		if !inPackage && !strings.HasPrefix(frame.Function, "runtime.") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
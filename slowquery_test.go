@@ -0,0 +1,55 @@
+package mysqlclient
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlowQuery(t *testing.T) {
+	var entries []*SlowQueryEntry
+	config := &Config{}
+	SlowQuery(SlowQueryConfig{
+		Threshold:     10 * time.Millisecond,
+		Logger:        func(entry *SlowQueryEntry) { entries = append(entries, entry) },
+		ArgSampleRate: 1,
+		MaxArgLength:  3,
+	})(config)
+	run := func(delay time.Duration) error {
+		call := &Call{Op: OpExec, SQL: "UPDATE user SET name = ? WHERE id = ?", Args: []interface{}{"abcdef", 1}}
+		return config.intercept(context.Background(), call, func(ctx context.Context, call *Call) error {
+			time.Sleep(delay)
+			call.Rows = 1
+			return nil
+		})
+	}
+	assert.Nil(t, run(0))
+	assert.Equal(t, 0, len(entries))
+	assert.Nil(t, run(20*time.Millisecond))
+	assert.Equal(t, 1, len(entries))
+	entry := entries[0]
+	assert.Equal(t, OpExec, entry.Op)
+	assert.EqualValues(t, []interface{}{"abc...", 1}, entry.Args)
+	assert.EqualValues(t, 1, entry.Rows)
+	assert.True(t, entry.Duration >= 20*time.Millisecond)
+	assert.True(t, strings.Contains(entry.Caller, "slowquery_test.go:"), entry.Caller)
+}
+
+func TestSlowQuery_Args(t *testing.T) {
+	args := []interface{}{"abcdef", []byte("abcdef"), 10}
+	var testCases = []struct {
+		config   SlowQueryConfig
+		expected []interface{}
+	}{
+		{config: SlowQueryConfig{}, expected: nil},
+		{config: SlowQueryConfig{ArgSampleRate: 1}, expected: args},
+		{config: SlowQueryConfig{ArgSampleRate: 1, MaxArgLength: 4}, expected: []interface{}{"abcd...", "abcd...", 10}},
+		{config: SlowQueryConfig{ArgSampleRate: 1, RedactArgs: true}, expected: []interface{}{"<string>", "<[]uint8>", "<int>"}},
+	}
+	for _, test := range testCases {
+		assert.EqualValues(t, test.expected, newSlowQueryInterceptor(test.config).captureArgs(args))
+	}
+}
@@ -0,0 +1,157 @@
+package mysqlclient
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+const defaultStmtCacheSize = 128
+
+// StmtCacheStats counts the lookups of the prepared statement cache.
+type StmtCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	// Size is the number of cached statements.
+	Size int
+}
+
+type stmtKey struct {
+	db    *sql.DB
+	query string
+}
+
+type stmtEntry struct {
+	key     stmtKey
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// stmtCache is a LRU cache of prepared statements keyed by pool and SQL
+// text. An evicted statement is closed once the calls using it are done.
+type stmtCache struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[stmtKey]*list.Element
+	stats   StmtCacheStats
+	closed  bool
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[stmtKey]*list.Element),
+	}
+}
+
+// lookup returns the cached statement of query on db, without preparing it
+// on a miss. The entry must be given back with release.
+func (c *stmtCache) lookup(db *sql.DB, query string) (*stmtEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[stmtKey{db: db, query: query}]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(element)
+	entry := element.Value.(*stmtEntry)
+	entry.refs++
+	return entry, true
+}
+
+// get returns the statement of query on db, preparing it on a miss. The
+// entry must be given back with release.
+func (c *stmtCache) get(ctx context.Context, db *sql.DB, query string) (*stmtEntry, error) {
+	if entry, ok := c.lookup(db, query); ok {
+		return entry, nil
+	}
+	key := stmtKey{db: db, query: query}
+
+	//prepare without holding the lock, another call may race us to it
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		go stmt.Close()
+		entry := element.Value.(*stmtEntry)
+		entry.refs++
+		return entry, nil
+	}
+	entry := &stmtEntry{key: key, stmt: stmt, refs: 1}
+	if c.closed {
+		entry.evicted = true
+		return entry, nil
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		c.evict(c.lru.Back())
+	}
+	return entry, nil
+}
+
+func (c *stmtCache) release(entry *stmtEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		go entry.stmt.Close()
+	}
+}
+
+// evict must be called with c.mu held.
+func (c *stmtCache) evict(element *list.Element) {
+	entry := c.lru.Remove(element).(*stmtEntry)
+	delete(c.entries, entry.key)
+	c.stats.Evictions++
+	entry.evicted = true
+	if entry.refs == 0 {
+		go entry.stmt.Close()
+	}
+}
+
+func (c *stmtCache) getStats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.lru.Len()
+	return stats
+}
+
+// close drops every statement; statements prepared afterwards are closed as
+// soon as they are released.
+func (c *stmtCache) close() {
+	var idle []*sql.Stmt
+	c.mu.Lock()
+	c.closed = true
+	for c.lru.Len() > 0 {
+		entry := c.lru.Remove(c.lru.Back()).(*stmtEntry)
+		delete(c.entries, entry.key)
+		entry.evicted = true
+		if entry.refs == 0 {
+			idle = append(idle, entry.stmt)
+		}
+	}
+	c.mu.Unlock()
+	for _, stmt := range idle {
+		_ = stmt.Close()
+	}
+}
+
+// StmtCacheStats returns the hit, miss and eviction counts of the prepared
+// statement cache, all zero when it is disabled.
+func (mc *MysqlClient) StmtCacheStats() StmtCacheStats {
+	if mc.stmts == nil {
+		return StmtCacheStats{}
+	}
+	return mc.stmts.getStats()
+}
@@ -0,0 +1,320 @@
+package mysqlclient
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/sillyhatxu/db-client/decoder"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+
+	structPlans sync.Map
+)
+
+type fieldScan int
+
+const (
+	//scan straight into the field address or its sql.Scanner
+	scanDirect fieldScan = iota
+	//time.Time and *time.Time, which must work with and without parseTime
+	scanTime
+	//everything else goes through the decoder package
+	scanDecode
+)
+
+type columnPlan struct {
+	name  string
+	kind  columnKind
+	index []int
+	scan  fieldScan
+}
+
+// structPlan maps the columns of a result set to the fields of a struct
+// type. Plans are cached per (struct type, columns) pair.
+type structPlan struct {
+	columns []columnPlan
+	config  *decoder.Config
+}
+
+type structPlanKey struct {
+	typ     reflect.Type
+	columns string
+}
+
+// isStructTarget reports whether typ is a struct the fast path can scan into.
+func isStructTarget(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && typ != timeType
+}
+
+func getStructPlan(typ reflect.Type, rows *sql.Rows) (*structPlan, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	kinds := make([]columnKind, len(columns))
+	nullables := make([]bool, len(columns))
+	var sb strings.Builder
+	for i, columnType := range columnTypes {
+		kinds[i] = getColumnKind(columnType)
+		nullable, ok := columnType.Nullable()
+		nullables[i] = nullable || !ok
+		fmt.Fprintf(&sb, "%s:%d:%t,", columns[i], kinds[i], nullables[i])
+	}
+	key := structPlanKey{typ: typ, columns: sb.String()}
+	if plan, ok := structPlans.Load(key); ok {
+		return plan.(*structPlan), nil
+	}
+	plan, err := newStructPlan(typ, columns, kinds, nullables)
+	if err != nil {
+		return nil, err
+	}
+	structPlans.Store(key, plan)
+	return plan, nil
+}
+
+func newStructPlan(typ reflect.Type, columns []string, kinds []columnKind, nullables []bool) (*structPlan, error) {
+	config := decoder.DefaultConfig()
+	fields := make(map[string]reflect.StructField)
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		tagValue := strings.SplitN(field.Tag.Get(config.TagName), ",", 2)[0]
+		if tagValue != "" {
+			name = tagValue
+		}
+		if _, ok := fields[name]; !ok {
+			names = append(names, name)
+		}
+		fields[name] = field
+	}
+	plan := &structPlan{
+		columns: make([]columnPlan, len(columns)),
+		config:  config,
+	}
+	var invalid []string
+	for i, column := range columns {
+		field, ok := fields[column]
+		if !ok {
+			//same case-insensitive fallback as the decoder
+			for _, name := range names {
+				if strings.EqualFold(name, column) {
+					field, ok = fields[name], true
+					break
+				}
+			}
+		}
+		if !ok {
+			invalid = append(invalid, column)
+			continue
+		}
+		plan.columns[i] = columnPlan{
+			name:  column,
+			kind:  kinds[i],
+			index: field.Index,
+			scan:  getFieldScan(field.Type, kinds[i], nullables[i]),
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return nil, fmt.Errorf("'%s' has invalid keys: %s", typ, strings.Join(invalid, ", "))
+	}
+	return plan, nil
+}
+
+func getFieldScan(typ reflect.Type, kind columnKind, nullable bool) fieldScan {
+	if reflect.PtrTo(typ).Implements(scannerType) {
+		return scanDirect
+	}
+	base := typ
+	if typ.Kind() == reflect.Ptr {
+		base = typ.Elem()
+		if reflect.PtrTo(base).Implements(scannerType) {
+			return scanDecode
+		}
+	}
+	if base == timeType {
+		return scanTime
+	}
+	if base.Kind() == reflect.Slice && base.Elem().Kind() == reflect.Uint8 {
+		return scanDirect
+	}
+	//NULL can't be stored in a plain value, let the decoder leave it zero
+	if base == typ && nullable {
+		return scanDecode
+	}
+	//bool is left to the decoder, database/sql only converts 0 and 1
+	switch base.Kind() {
+	case reflect.String:
+		return scanDirect
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if kind == intColumn || kind == uintColumn {
+			return scanDirect
+		}
+	case reflect.Float32, reflect.Float64:
+		if kind == intColumn || kind == uintColumn || kind == floatColumn || kind == decimalColumn {
+			return scanDirect
+		}
+	}
+	return scanDecode
+}
+
+// scan reads the current row of rows into dest, an addressable struct.
+func (p *structPlan) scan(rows *sql.Rows, dest reflect.Value) error {
+	scans := make([]interface{}, len(p.columns))
+	for i := range p.columns {
+		column := &p.columns[i]
+		field := dest.FieldByIndex(column.index)
+		switch column.scan {
+		case scanDirect:
+			scans[i] = field.Addr().Interface()
+		case scanTime:
+			scans[i] = &timeScanner{field: field}
+		default:
+			scans[i] = &decodeScanner{column: column, field: field, config: p.config}
+		}
+	}
+	return rows.Scan(scans...)
+}
+
+type timeScanner struct {
+	field reflect.Value
+}
+
+func (s *timeScanner) Scan(src interface{}) error {
+	if src == nil {
+		s.field.Set(reflect.Zero(s.field.Type()))
+		return nil
+	}
+	var value interface{} = src
+	switch v := src.(type) {
+	case []byte:
+		value = parseTime(string(v))
+	case string:
+		value = parseTime(v)
+	}
+	t, ok := value.(time.Time)
+	if !ok {
+		return fmt.Errorf("cannot parse %#v as time", src)
+	}
+	if s.field.Kind() == reflect.Ptr {
+		s.field.Set(reflect.ValueOf(&t))
+	} else {
+		s.field.Set(reflect.ValueOf(t))
+	}
+	return nil
+}
+
+type decodeScanner struct {
+	column *columnPlan
+	field  reflect.Value
+	config *decoder.Config
+}
+
+func (s *decodeScanner) Scan(src interface{}) error {
+	//the driver may reuse the buffer after Scan returns
+	if b, ok := src.([]byte); ok {
+		src = append([]byte(nil), b...)
+	}
+	value, err := convertColumn(s.column.name, s.column.kind, src)
+	if err != nil {
+		return err
+	}
+	return s.config.Decode(value, s.field.Addr().Interface())
+}
+
+// scanStructs reads every row into output, a slice of structs or of struct
+// pointers, and returns the number of rows read. The output is left
+// untouched when there are no rows.
+func scanStructs(rows *sql.Rows, output reflect.Value, elemType reflect.Type) (int64, error) {
+	structType := elemType
+	if elemType.Kind() == reflect.Ptr {
+		structType = elemType.Elem()
+	}
+	var plan *structPlan
+	var results reflect.Value
+	for rows.Next() {
+		if plan == nil {
+			var err error
+			plan, err = getStructPlan(structType, rows)
+			if err != nil {
+				return 0, err
+			}
+			results = reflect.MakeSlice(output.Type(), 0, 0)
+		}
+		item := reflect.New(structType)
+		if err := plan.scan(rows, item.Elem()); err != nil {
+			return 0, err
+		}
+		if elemType.Kind() == reflect.Ptr {
+			results = reflect.Append(results, item)
+		} else {
+			results = reflect.Append(results, item.Elem())
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, wrapError(err)
+	}
+	if plan == nil {
+		return 0, nil
+	}
+	output.Set(results)
+	return int64(results.Len()), nil
+}
+
+// scanStruct reads the first row into output, an addressable struct, and
+// returns the number of rows read.
+func scanStruct(rows *sql.Rows, output reflect.Value) (int64, error) {
+	if !rows.Next() {
+		return 0, wrapError(rows.Err())
+	}
+	plan, err := getStructPlan(output.Type(), rows)
+	if err != nil {
+		return 0, err
+	}
+	item := reflect.New(output.Type())
+	if err := plan.scan(rows, item.Elem()); err != nil {
+		return 0, err
+	}
+	output.Set(item.Elem())
+	return 1, nil
+}
+
+func structSliceTarget(output interface{}) (reflect.Value, reflect.Type, bool) {
+	outVal := reflect.ValueOf(output)
+	if outVal.Kind() != reflect.Ptr || outVal.IsNil() || outVal.Elem().Kind() != reflect.Slice {
+		return reflect.Value{}, nil, false
+	}
+	elemType := outVal.Elem().Type().Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Ptr {
+		structType = elemType.Elem()
+	}
+	if !isStructTarget(structType) {
+		return reflect.Value{}, nil, false
+	}
+	return outVal.Elem(), elemType, true
+}
+
+func structTarget(output interface{}) (reflect.Value, bool) {
+	outVal := reflect.ValueOf(output)
+	if outVal.Kind() != reflect.Ptr || outVal.IsNil() || !isStructTarget(outVal.Elem().Type()) {
+		return reflect.Value{}, false
+	}
+	return outVal.Elem(), true
+}
@@ -0,0 +1,77 @@
+package mysqlclient
+
+import (
+	"database/sql"
+	"github.com/sillyhatxu/db-client/decoder"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetFieldScan(t *testing.T) {
+	var data = []struct {
+		value    interface{}
+		kind     columnKind
+		nullable bool
+		out      fieldScan
+	}{
+		{value: int64(0), kind: intColumn, nullable: false, out: scanDirect},
+		{value: int64(0), kind: intColumn, nullable: true, out: scanDecode},
+		{value: new(int), kind: intColumn, nullable: true, out: scanDirect},
+		{value: "", kind: stringColumn, nullable: false, out: scanDirect},
+		{value: new(string), kind: stringColumn, nullable: true, out: scanDirect},
+		{value: false, kind: intColumn, nullable: false, out: scanDecode},
+		{value: new(bool), kind: uintColumn, nullable: true, out: scanDecode},
+		{value: false, kind: stringColumn, nullable: false, out: scanDecode},
+		{value: float64(0), kind: decimalColumn, nullable: false, out: scanDirect},
+		{value: []byte(nil), kind: bytesColumn, nullable: true, out: scanDirect},
+		{value: time.Time{}, kind: timeColumn, nullable: true, out: scanTime},
+		{value: new(time.Time), kind: timeColumn, nullable: true, out: scanTime},
+		{value: sql.NullString{}, kind: stringColumn, nullable: true, out: scanDirect},
+		{value: []string(nil), kind: stringColumn, nullable: false, out: scanDecode},
+	}
+	for _, d := range data {
+		assert.Equal(t, d.out, getFieldScan(reflect.TypeOf(d.value), d.kind, d.nullable), "%T", d.value)
+	}
+}
+
+func TestDecodeScanner_Bool(t *testing.T) {
+	var data = []struct {
+		src  interface{}
+		kind columnKind
+		out  bool
+	}{
+		{src: int64(0), kind: intColumn, out: false},
+		{src: int64(1), kind: intColumn, out: true},
+		{src: int64(2), kind: intColumn, out: true},
+		{src: int64(-1), kind: intColumn, out: true},
+		{src: []byte("2"), kind: uintColumn, out: true},
+	}
+	for _, d := range data {
+		var out bool
+		s := &decodeScanner{
+			column: &columnPlan{name: "status", kind: d.kind},
+			field:  reflect.ValueOf(&out).Elem(),
+			config: decoder.DefaultConfig(),
+		}
+		assert.Nil(t, s.Scan(d.src))
+		assert.Equal(t, d.out, out, "%v", d.src)
+	}
+}
+
+func TestNewStructPlan(t *testing.T) {
+	type user struct {
+		Id        int64  `column:"id"`
+		LoginName string `column:"login_name"`
+		Platform  string
+	}
+	columns := []string{"id", "LOGIN_NAME", "platform"}
+	plan, err := newStructPlan(reflect.TypeOf(user{}), columns, []columnKind{intColumn, stringColumn, stringColumn}, []bool{false, false, true})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []int{1}, plan.columns[1].index)
+	assert.EqualValues(t, scanDecode, plan.columns[2].scan)
+
+	_, err = newStructPlan(reflect.TypeOf(user{}), []string{"id", "age"}, []columnKind{intColumn, intColumn}, []bool{false, false})
+	assert.NotNil(t, err)
+}
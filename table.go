@@ -0,0 +1,357 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/sillyhatxu/db-client/builder"
+	"strings"
+)
+
+var errNoConditions = errors.New("refusing to update or delete a whole table, add a Where")
+
+// TableQuery is a query on one table built by chaining. Every chained call
+// returns a new TableQuery, so a partly built query can be reused.
+type TableQuery struct {
+	client  *MysqlClient
+	tx      *Tx
+	table   string
+	fields  []string
+	where   []builder.Comparable
+	groupBy []string
+	having  []builder.Comparable
+	orderBy []string
+	limit   []uint
+	offset  uint
+	lock    string
+	err     error
+}
+
+// Table starts a query on table.
+func (mc *MysqlClient) Table(table string) *TableQuery {
+	return &TableQuery{client: mc, table: table}
+}
+
+// Table starts a query on table run in the transaction.
+func (tx *Tx) Table(table string) *TableQuery {
+	return &TableQuery{tx: tx, table: table}
+}
+
+func (q *TableQuery) clone() *TableQuery {
+	c := *q
+	c.fields = append([]string(nil), q.fields...)
+	c.where = append([]builder.Comparable(nil), q.where...)
+	c.groupBy = append([]string(nil), q.groupBy...)
+	c.having = append([]builder.Comparable(nil), q.having...)
+	c.orderBy = append([]string(nil), q.orderBy...)
+	c.limit = append([]uint(nil), q.limit...)
+	return &c
+}
+
+func toConditions(condition interface{}) ([]builder.Comparable, error) {
+	switch c := condition.(type) {
+	case map[string]interface{}:
+		return builder.Conditions(c)
+	case builder.Comparable:
+		if err := checkCondition(c); err != nil {
+			return nil, err
+		}
+		return []builder.Comparable{c}, nil
+	}
+	return nil, fmt.Errorf("condition must be a map[string]interface{} or a builder.Comparable, got %T", condition)
+}
+
+// checkCondition rejects an In or NotIn without values, which renders
+// "IN ()", as builder.Conditions does for a where map.
+func checkCondition(c builder.Comparable) error {
+	var lists map[string][]interface{}
+	var op string
+	switch v := c.(type) {
+	case builder.In:
+		lists, op = v, "in"
+	case builder.NotIn:
+		lists, op = v, "not in"
+	case builder.NestWhere:
+		return checkConditions(v)
+	case builder.OrWhere:
+		return checkConditions(v)
+	}
+	for field, vals := range lists {
+		if len(vals) == 0 {
+			return fmt.Errorf("the values of %s %s must contain at least one element", field, op)
+		}
+	}
+	return nil
+}
+
+func checkConditions(conditions []builder.Comparable) error {
+	for _, c := range conditions {
+		if err := checkCondition(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasConditions reports whether the conditions render a WHERE clause, an
+// empty Eq{} or NestWhere{} renders none.
+func hasConditions(conditions []builder.Comparable) bool {
+	for _, c := range conditions {
+		cons, _ := c.Build()
+		for _, con := range cons {
+			if con != "" && con != "()" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Where adds conditions joined with AND. Each one is a where map as taken by
+// builder.BuildSelect, without the special keys, or a builder.Comparable.
+func (q *TableQuery) Where(conditions ...interface{}) *TableQuery {
+	c := q.clone()
+	for _, condition := range conditions {
+		comparables, err := toConditions(condition)
+		if err != nil && c.err == nil {
+			c.err = err
+		}
+		c.where = append(c.where, comparables...)
+	}
+	return c
+}
+
+// Select sets the selected fields, * by default.
+func (q *TableQuery) Select(fields ...string) *TableQuery {
+	c := q.clone()
+	c.fields = append(c.fields, fields...)
+	return c
+}
+
+// OrderBy adds sort expressions such as "id DESC".
+func (q *TableQuery) OrderBy(orderBy ...string) *TableQuery {
+	c := q.clone()
+	c.orderBy = append(c.orderBy, orderBy...)
+	return c
+}
+
+func (q *TableQuery) Limit(limit uint) *TableQuery {
+	c := q.clone()
+	c.limit = []uint{limit}
+	return c
+}
+
+// Offset skips rows, it needs a Limit.
+func (q *TableQuery) Offset(offset uint) *TableQuery {
+	c := q.clone()
+	c.offset = offset
+	return c
+}
+
+// ForUpdate locks the selected rows (SELECT ... FOR UPDATE), which only makes
+// sense in a transaction. It is never sent to a replica.
+func (q *TableQuery) ForUpdate() *TableQuery {
+	c := q.clone()
+	c.lock = "exclusive"
+	return c
+}
+
+func (q *TableQuery) GroupBy(fields ...string) *TableQuery {
+	c := q.clone()
+	c.groupBy = append(c.groupBy, fields...)
+	return c
+}
+
+// Having adds conditions on the groups, taken as by Where. It needs a
+// GroupBy.
+func (q *TableQuery) Having(conditions ...interface{}) *TableQuery {
+	c := q.clone()
+	for _, condition := range conditions {
+		comparables, err := toConditions(condition)
+		if err != nil && c.err == nil {
+			c.err = err
+		}
+		c.having = append(c.having, comparables...)
+	}
+	return c
+}
+
+func (q *TableQuery) selectQuery() builder.SelectQuery {
+	query := builder.SelectQuery{
+		Table:    q.table,
+		Fields:   q.fields,
+		Where:    q.where,
+		GroupBy:  strings.Join(q.groupBy, ","),
+		Having:   q.having,
+		OrderBy:  strings.Join(q.orderBy, ","),
+		LockMode: q.lock,
+	}
+	if len(q.limit) > 0 {
+		query.Limit = []uint{q.offset, q.limit[0]}
+	}
+	return query
+}
+
+func (q *TableQuery) build() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	return builder.BuildSelectQuery(q.selectQuery())
+}
+
+func (q *TableQuery) getContext() (context.Context, context.CancelFunc) {
+	if q.tx != nil {
+		return q.tx.getContext()
+	}
+	return q.client.getContext()
+}
+
+// reader returns the executor of the selects, the primary for locking reads
+// as a replica can't lock the rows of the primary.
+func (q *TableQuery) reader(ctx context.Context) *executor {
+	if q.tx != nil {
+		return q.tx.executor
+	}
+	if q.lock != "" {
+		return q.client.executor()
+	}
+	return q.client.readExecutor(ctx)
+}
+
+func (q *TableQuery) writer() *executor {
+	if q.tx != nil {
+		return q.tx.executor
+	}
+	return q.client.executor()
+}
+
+func (q *TableQuery) Find(output interface{}) error {
+	ctx, cancel := q.getContext()
+	defer cancel()
+	return q.FindContext(ctx, output)
+}
+
+// FindContext reads every selected row into output as Find does.
+func (q *TableQuery) FindContext(ctx context.Context, output interface{}) error {
+	query, args, err := q.build()
+	if err != nil {
+		return err
+	}
+	return q.reader(ctx).find(ctx, query, output, args...)
+}
+
+func (q *TableQuery) First(output interface{}) error {
+	ctx, cancel := q.getContext()
+	defer cancel()
+	return q.FirstContext(ctx, output)
+}
+
+// FirstContext reads the first selected row into output, it returns
+// sql.ErrNoRows when there is none.
+func (q *TableQuery) FirstContext(ctx context.Context, output interface{}) error {
+	if len(q.limit) == 0 {
+		q = q.Limit(1)
+	}
+	query, args, err := q.build()
+	if err != nil {
+		return err
+	}
+	found, err := q.reader(ctx).findFirst(ctx, query, output, args...)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (q *TableQuery) Count() (int64, error) {
+	ctx, cancel := q.getContext()
+	defer cancel()
+	return q.CountContext(ctx)
+}
+
+// CountContext counts the rows matching the conditions, or the groups when
+// there is a GroupBy. Select, OrderBy, Limit and ForUpdate are ignored.
+func (q *TableQuery) CountContext(ctx context.Context) (int64, error) {
+	c := q.clone()
+	c.fields, c.orderBy, c.limit, c.offset, c.lock = []string{"count(1)"}, nil, nil, 0, ""
+	if len(c.groupBy) > 0 {
+		c.fields = []string{"1"}
+	}
+	query, args, err := c.build()
+	if err != nil {
+		return 0, err
+	}
+	if len(c.groupBy) > 0 {
+		query = "SELECT count(1) FROM (" + query + ") t"
+	}
+	return q.reader(ctx).count(ctx, query, args...)
+}
+
+func (q *TableQuery) Exists() (bool, error) {
+	ctx, cancel := q.getContext()
+	defer cancel()
+	return q.ExistsContext(ctx)
+}
+
+// ExistsContext reports whether a row matches the conditions.
+func (q *TableQuery) ExistsContext(ctx context.Context) (bool, error) {
+	c := q.clone()
+	c.fields, c.orderBy, c.limit, c.offset, c.lock = []string{"1"}, nil, []uint{1}, 0, ""
+	query, args, err := c.build()
+	if err != nil {
+		return false, err
+	}
+	exists, err := q.reader(ctx).count(ctx, "SELECT EXISTS("+query+")", args...)
+	if err != nil {
+		return false, err
+	}
+	return exists == 1, nil
+}
+
+func (q *TableQuery) Update(update map[string]interface{}) (int64, error) {
+	ctx, cancel := q.getContext()
+	defer cancel()
+	return q.UpdateContext(ctx, update)
+}
+
+// UpdateContext updates the rows matching the conditions and returns the
+// number of rows affected. It refuses to run without a Where.
+func (q *TableQuery) UpdateContext(ctx context.Context, update map[string]interface{}) (int64, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	if !hasConditions(q.where) {
+		return 0, errNoConditions
+	}
+	query, args, err := builder.BuildUpdateWhere(q.table, update, q.where...)
+	if err != nil {
+		return 0, err
+	}
+	return q.writer().rowsAffected(ctx, query, args...)
+}
+
+func (q *TableQuery) Delete() (int64, error) {
+	ctx, cancel := q.getContext()
+	defer cancel()
+	return q.DeleteContext(ctx)
+}
+
+// DeleteContext deletes the rows matching the conditions and returns the
+// number of rows affected. It refuses to run without a Where.
+func (q *TableQuery) DeleteContext(ctx context.Context) (int64, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	if !hasConditions(q.where) {
+		return 0, errNoConditions
+	}
+	query, args, err := builder.BuildDeleteWhere(q.table, q.where...)
+	if err != nil {
+		return 0, err
+	}
+	return q.writer().rowsAffected(ctx, query, args...)
+}
@@ -0,0 +1,75 @@
+package mysqlclient
+
+import (
+	"context"
+	"github.com/sillyhatxu/db-client/builder"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTableQuery_Build(t *testing.T) {
+	mc := &MysqlClient{}
+	users := mc.Table("user").Where(map[string]interface{}{"status": true})
+	var testCases = []struct {
+		query *TableQuery
+		sql   string
+		args  []interface{}
+	}{
+		{query: mc.Table("user"), sql: "SELECT * FROM user"},
+		{query: users, sql: "SELECT * FROM user WHERE (status=?)", args: []interface{}{true}},
+		{
+			query: users.Where(builder.Like{"login_name": "a%"}).Select("id", "login_name").OrderBy("id DESC").Limit(10).Offset(20),
+			sql:   "SELECT id,login_name FROM user WHERE (status=? AND login_name LIKE ?) ORDER BY id DESC LIMIT ?,?",
+			args:  []interface{}{true, "a%", 20, 10},
+		},
+		{
+			query: users.Select("platform", "count(1) as total").GroupBy("platform").Having(map[string]interface{}{"total >": 1}),
+			sql:   "SELECT platform,count(1) as total FROM user WHERE (status=?) GROUP BY platform HAVING (total>?)",
+			args:  []interface{}{true, 1},
+		},
+		{
+			query: users.Where(map[string]interface{}{"id": 1}).ForUpdate(),
+			sql:   "SELECT * FROM user WHERE (status=? AND id=?) FOR UPDATE",
+			args:  []interface{}{true, 1},
+		},
+	}
+	for _, test := range testCases {
+		sql, args, err := test.query.build()
+		assert.Nil(t, err)
+		assert.Equal(t, test.sql, sql)
+		assert.EqualValues(t, test.args, args)
+	}
+}
+
+func TestTableQuery_Errors(t *testing.T) {
+	mc := &MysqlClient{}
+	_, _, err := mc.Table("user").Where("id = 1").build()
+	assert.NotNil(t, err)
+	_, _, err = mc.Table("user").Where(map[string]interface{}{"_limit": []uint{1}}).build()
+	assert.NotNil(t, err)
+	_, err = mc.Table("user").UpdateContext(context.Background(), map[string]interface{}{"status": false})
+	assert.Equal(t, errNoConditions, err)
+	_, err = mc.Table("user").DeleteContext(context.Background())
+	assert.Equal(t, errNoConditions, err)
+	_, err = mc.Table("user").Where(builder.Eq{}).UpdateContext(context.Background(), map[string]interface{}{"status": false})
+	assert.Equal(t, errNoConditions, err)
+	_, err = mc.Table("user").Where(builder.Eq{}, builder.NestWhere{builder.Eq{}}).DeleteContext(context.Background())
+	assert.Equal(t, errNoConditions, err)
+	_, err = mc.Table("user").Where(map[string]interface{}{}).DeleteContext(context.Background())
+	assert.Equal(t, errNoConditions, err)
+	_, err = mc.Table("user").Where(builder.In{"id": nil}).DeleteContext(context.Background())
+	assert.NotNil(t, err)
+	assert.NotEqual(t, errNoConditions, err)
+	_, _, err = mc.Table("user").Where(builder.OrWhere{builder.NotIn{"id": []interface{}{}}}).build()
+	assert.NotNil(t, err)
+}
+
+func TestTableQuery_Reader(t *testing.T) {
+	pools := openReplicas(t, 2)
+	mc := &MysqlClient{config: &Config{pool: pools[0]}, replicas: newReplicaSet(pools[1:], RoundRobin)}
+	ctx := context.Background()
+	users := mc.Table("user").Where(map[string]interface{}{"id": 1})
+	assert.Equal(t, pools[1], users.reader(ctx).db)
+	assert.Equal(t, pools[0], users.ForUpdate().reader(ctx).db)
+	assert.Equal(t, pools[0], users.writer().db)
+}
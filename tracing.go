@@ -0,0 +1,176 @@
+package mysqlclient
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	migrationSpanName = "mysql.migrate"
+
+	AttrSystem       = "db.system"
+	AttrStatement    = "db.statement"
+	AttrOperation    = "db.operation"
+	AttrTable        = "db.sql.table"
+	AttrRowsAffected = "db.rows_affected"
+	AttrInTx         = "db.in_transaction"
+)
+
+// Tracer starts spans for the calls of MysqlClient. The returned context
+// carries the span so that calls made with it, e.g. the statements of a
+// transaction, become its children.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// NoopTracer is the default Tracer, it records nothing.
+type NoopTracer struct{}
+
+func (NoopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+
+func (noopSpan) RecordError(err error) {}
+
+func (noopSpan) End() {}
+
+// RecordingTracer keeps every span in memory, for tests.
+type RecordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+// RecordedSpan is the snapshot of a span of a RecordingTracer. ParentID is
+// 0 for root spans.
+type RecordedSpan struct {
+	ID         int
+	ParentID   int
+	Name       string
+	Attributes map[string]interface{}
+	Err        error
+	Ended      bool
+	Duration   time.Duration
+}
+
+type recordingSpan struct {
+	tracer *RecordingTracer
+	span   RecordedSpan
+	startT time.Time
+}
+
+type recordingSpanKey struct{}
+
+func NewRecordingTracer() *RecordingTracer {
+	return &RecordingTracer{}
+}
+
+func (t *RecordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &recordingSpan{
+		tracer: t,
+		span:   RecordedSpan{ID: len(t.spans) + 1, Name: name, Attributes: make(map[string]interface{})},
+		startT: time.Now(),
+	}
+	if parent, ok := ctx.Value(recordingSpanKey{}).(*recordingSpan); ok && parent.tracer == t {
+		span.span.ParentID = parent.span.ID
+	}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, recordingSpanKey{}, span), span
+}
+
+// Spans returns the spans started so far in start order.
+func (t *RecordingTracer) Spans() []RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := make([]RecordedSpan, len(t.spans))
+	for i, s := range t.spans {
+		spans[i] = s.span
+		spans[i].Attributes = make(map[string]interface{}, len(s.span.Attributes))
+		for key, value := range s.span.Attributes {
+			spans[i].Attributes[key] = value
+		}
+	}
+	return spans
+}
+
+// Reset drops the recorded spans.
+func (t *RecordingTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+func (s *recordingSpan) SetAttribute(key string, value interface{}) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.span.Attributes[key] = value
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.span.Err = err
+}
+
+func (s *recordingSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	if !s.span.Ended {
+		s.span.Ended = true
+		s.span.Duration = time.Since(s.startT)
+	}
+}
+
+type tracingInterceptor struct {
+	tracer Tracer
+}
+
+func (i *tracingInterceptor) Intercept(ctx context.Context, call *Call, next Handler) error {
+	ctx, span := i.tracer.Start(ctx, "mysql."+string(call.Op))
+	defer span.End()
+	span.SetAttribute(AttrSystem, "mysql")
+	span.SetAttribute(AttrOperation, metricsOperation(call))
+	if call.InTx {
+		span.SetAttribute(AttrInTx, true)
+	}
+	err := next(ctx, call)
+	//interceptors may have rewritten the statement
+	if call.SQL != "" {
+		span.SetAttribute(AttrStatement, call.SQL)
+		if table := parseTable(call.SQL); table != "" {
+			span.SetAttribute(AttrTable, table)
+		}
+	}
+	if call.Rows >= 0 {
+		span.SetAttribute(AttrRowsAffected, call.Rows)
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	return err
+}
+
+var tableRegexp = regexp.MustCompile("(?i)\\b(?:from|into|update|table(?:\\s+if\\s+(?:not\\s+)?exists)?)\\s+`?([\\w$]+(?:`?\\.`?[\\w$]+)?)")
+
+// parseTable returns the first table named by a statement, or "".
+func parseTable(sql string) string {
+	match := tableRegexp.FindStringSubmatch(sql)
+	if match == nil {
+		return ""
+	}
+	return strings.Replace(match[1], "`", "", -1)
+}
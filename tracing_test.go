@@ -0,0 +1,55 @@
+package mysqlclient
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseTable(t *testing.T) {
+	var testCases = []struct {
+		sql      string
+		expected string
+	}{
+		{sql: "select * from user where id = ?", expected: "user"},
+		{sql: "SELECT count(1) FROM `db`.`user` u JOIN role r ON u.role_id = r.id", expected: "db.user"},
+		{sql: "INSERT INTO user_info (name) VALUES (?)", expected: "user_info"},
+		{sql: "update `user` set name = ?", expected: "user"},
+		{sql: "DELETE FROM user WHERE id = ?", expected: "user"},
+		{sql: "CREATE TABLE IF NOT EXISTS schema_version (id bigint)", expected: "schema_version"},
+		{sql: "SAVEPOINT sp_1", expected: ""},
+	}
+	for _, test := range testCases {
+		assert.Equal(t, test.expected, parseTable(test.sql), test.sql)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	tracer := NewRecordingTracer()
+	config := &Config{}
+	Tracing(tracer)(config)
+	errUpdate := errors.New("update failed")
+	err := config.intercept(context.Background(), &Call{Op: OpTransaction, Rows: -1}, func(ctx context.Context, txCall *Call) error {
+		call := &Call{Op: OpExec, SQL: "UPDATE user SET name = ?", InTx: true, Rows: -1}
+		return config.intercept(ctx, call, func(ctx context.Context, call *Call) error {
+			return errUpdate
+		})
+	})
+	assert.Equal(t, errUpdate, err)
+	spans := tracer.Spans()
+	assert.Equal(t, 2, len(spans))
+	assert.Equal(t, "mysql.transaction", spans[0].Name)
+	assert.Equal(t, 0, spans[0].ParentID)
+	assert.Equal(t, "tx", spans[0].Attributes[AttrOperation])
+	assert.Equal(t, "mysql.exec", spans[1].Name)
+	assert.Equal(t, spans[0].ID, spans[1].ParentID)
+	assert.Equal(t, "UPDATE user SET name = ?", spans[1].Attributes[AttrStatement])
+	assert.Equal(t, "user", spans[1].Attributes[AttrTable])
+	assert.Equal(t, true, spans[1].Attributes[AttrInTx])
+	assert.Nil(t, spans[1].Attributes[AttrRowsAffected])
+	for _, span := range spans {
+		assert.True(t, span.Ended)
+		assert.Equal(t, errUpdate, span.Err)
+	}
+}
@@ -0,0 +1,243 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+)
+
+// Tx is a transaction-scoped handle with the same query and DML surface as
+// MysqlClient. Methods without a context derive one from the transaction
+// context and the client timeout.
+type Tx struct {
+	ctx        context.Context
+	tx         *sql.Tx
+	client     *MysqlClient
+	config     *Config
+	executor   *executor
+	savepoints int64
+}
+
+type TxCallback func(context.Context, *Tx) error
+
+type txContextKey struct{}
+
+// TxFromContext returns the transaction that the context passed to a
+// TxCallback or TransactionCallback belongs to.
+func TxFromContext(ctx context.Context) (*Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*Tx)
+	return tx, ok
+}
+
+// WithTx always begins a new transaction, even when called from a callback.
+// Nesting in a savepoint takes WithTxContext with the ctx of the callback.
+func (mc *MysqlClient) WithTx(callback TxCallback, opts ...TxOption) error {
+	ctx, cancel := mc.getTxContext(newTxConfig(opts))
+	defer cancel()
+	return mc.WithTxContext(ctx, callback, opts...)
+}
+
+// WithTxContext runs callback inside a transaction bound to ctx. The
+// transaction is committed if callback returns nil and rolled back otherwise,
+// and the whole callback is run again on deadlocks and lock wait timeouts as
+// allowed by the retry policy. If ctx already carries a transaction of this
+// client, the callback runs in a savepoint of that transaction instead, see
+// Tx.WithTxContext; opts are ignored then since a savepoint can't change
+// them.
+func (mc *MysqlClient) WithTxContext(ctx context.Context, callback TxCallback, opts ...TxOption) error {
+	if parent, ok := TxFromContext(ctx); ok && parent.client == mc {
+		return parent.WithTxContext(ctx, callback)
+	}
+	if err := mc.gate.enter(ctx); err != nil {
+		return err
+	}
+	defer mc.gate.leave()
+	//the callback may call mc with ctx while Close waits for it
+	ctx = mc.gate.hold(ctx)
+	config := newTxConfig(opts)
+	if config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.timeout)
+		defer cancel()
+	}
+	policy := mc.config.txRetry
+	if config.retry != nil {
+		policy = *config.retry
+	}
+	attempts, err := policy.run(ctx, func() error {
+		return mc.runTx(ctx, config, callback)
+	})
+	if config.attempts != nil {
+		*config.attempts = attempts
+	}
+	return err
+}
+
+func (mc *MysqlClient) runTx(ctx context.Context, config *txConfig, callback TxCallback) error {
+	call := &Call{Op: OpTransaction, Rows: -1}
+	return mc.config.intercept(ctx, call, func(ctx context.Context, call *Call) error {
+		sqlTx, err := mc.GetDB().BeginTx(ctx, &config.options)
+		if err != nil {
+			return wrapError(err)
+		}
+		executor := mc.newExecutor(sqlTx, mc.GetDB())
+		executor.inTx = true
+		tx := &Tx{
+			tx:       sqlTx,
+			client:   mc,
+			config:   mc.config,
+			executor: executor,
+		}
+		ctx = context.WithValue(ctx, txContextKey{}, tx)
+		tx.ctx = ctx
+		err = callback(ctx, tx)
+		if err != nil {
+			_ = sqlTx.Rollback()
+			return ClassifyError(err)
+		}
+		return wrapError(sqlTx.Commit())
+	})
+}
+
+func (tx *Tx) WithTx(callback TxCallback) error {
+	ctx, cancel := tx.getContext()
+	defer cancel()
+	return tx.WithTxContext(ctx, callback)
+}
+
+// WithTxContext runs callback as a nested transaction using SAVEPOINT. If
+// callback fails only its own work is rolled back (ROLLBACK TO SAVEPOINT)
+// and the error is returned; the outer transaction rolls back as a whole
+// only if that error is propagated.
+func (tx *Tx) WithTxContext(ctx context.Context, callback TxCallback) error {
+	savepoint := fmt.Sprintf("sp_%d", atomic.AddInt64(&tx.savepoints, 1))
+	call := &Call{Op: OpTransaction, SQL: "SAVEPOINT " + savepoint, InTx: true, Rows: -1}
+	return tx.config.intercept(ctx, call, func(ctx context.Context, call *Call) error {
+		//savepoint statements use the transaction context, ctx may be shorter lived
+		if _, err := tx.tx.ExecContext(tx.ctx, "SAVEPOINT "+savepoint); err != nil {
+			return wrapError(err)
+		}
+		err := callback(ctx, tx)
+		if err != nil {
+			_, _ = tx.tx.ExecContext(tx.ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+			return ClassifyError(err)
+		}
+		_, err = tx.tx.ExecContext(tx.ctx, "RELEASE SAVEPOINT "+savepoint)
+		return wrapError(err)
+	})
+}
+
+func (tx *Tx) getContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(tx.ctx, tx.config.timeout)
+}
+
+// Raw returns the underlying *sql.Tx.
+func (tx *Tx) Raw() *sql.Tx {
+	return tx.tx
+}
+
+func (tx *Tx) Exec(sql string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := tx.getContext()
+	defer cancel()
+	return tx.ExecContext(ctx, sql, args...)
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, sql string, args ...interface{}) (sql.Result, error) {
+	return tx.executor.exec(ctx, sql, args...)
+}
+
+func (tx *Tx) Insert(sql string, args ...interface{}) (int64, error) {
+	ctx, cancel := tx.getContext()
+	defer cancel()
+	return tx.InsertContext(ctx, sql, args...)
+}
+
+func (tx *Tx) InsertContext(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return tx.executor.insert(ctx, sql, args...)
+}
+
+func (tx *Tx) Update(sql string, args ...interface{}) (int64, error) {
+	ctx, cancel := tx.getContext()
+	defer cancel()
+	return tx.UpdateContext(ctx, sql, args...)
+}
+
+func (tx *Tx) UpdateContext(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return tx.executor.rowsAffected(ctx, sql, args...)
+}
+
+func (tx *Tx) Delete(sql string, args ...interface{}) (int64, error) {
+	ctx, cancel := tx.getContext()
+	defer cancel()
+	return tx.DeleteContext(ctx, sql, args...)
+}
+
+func (tx *Tx) DeleteContext(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return tx.executor.rowsAffected(ctx, sql, args...)
+}
+
+func (tx *Tx) Count(sql string, args ...interface{}) (int64, error) {
+	ctx, cancel := tx.getContext()
+	defer cancel()
+	return tx.CountContext(ctx, sql, args...)
+}
+
+func (tx *Tx) CountContext(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	return tx.executor.count(ctx, sql, args...)
+}
+
+func (tx *Tx) FindCustom(query string, fieldFunc FieldFunc, args ...interface{}) error {
+	ctx, cancel := tx.getContext()
+	defer cancel()
+	return tx.FindCustomContext(ctx, query, fieldFunc, args...)
+}
+
+func (tx *Tx) FindCustomContext(ctx context.Context, query string, fieldFunc FieldFunc, args ...interface{}) error {
+	return tx.executor.findCustom(ctx, query, fieldFunc, args...)
+}
+
+func (tx *Tx) Find(sql string, output interface{}, args ...interface{}) error {
+	ctx, cancel := tx.getContext()
+	defer cancel()
+	return tx.FindContext(ctx, sql, output, args...)
+}
+
+func (tx *Tx) FindContext(ctx context.Context, sql string, output interface{}, args ...interface{}) error {
+	return tx.executor.find(ctx, sql, output, args...)
+}
+
+func (tx *Tx) FindFirst(sql string, output interface{}, args ...interface{}) error {
+	ctx, cancel := tx.getContext()
+	defer cancel()
+	return tx.FindFirstContext(ctx, sql, output, args...)
+}
+
+func (tx *Tx) FindFirstContext(ctx context.Context, sql string, output interface{}, args ...interface{}) error {
+	_, err := tx.executor.findFirst(ctx, sql, output, args...)
+	return err
+}
+
+func (tx *Tx) FindMapArray(sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	ctx, cancel := tx.getContext()
+	defer cancel()
+	return tx.FindMapArrayContext(ctx, sql, args...)
+}
+
+func (tx *Tx) FindMapArrayContext(ctx context.Context, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	return tx.executor.findMapArray(ctx, sql, args...)
+}
+
+func (tx *Tx) QueryCursor(ctx context.Context, sql string, args ...interface{}) (*Cursor, error) {
+	return tx.executor.queryCursor(ctx, sql, args...)
+}
+
+func (tx *Tx) FindEach(sql string, rowFunc RowFunc, args ...interface{}) error {
+	ctx, cancel := tx.getContext()
+	defer cancel()
+	return tx.FindEachContext(ctx, sql, rowFunc, args...)
+}
+
+func (tx *Tx) FindEachContext(ctx context.Context, sql string, rowFunc RowFunc, args ...interface{}) error {
+	return tx.executor.findEach(ctx, sql, rowFunc, args...)
+}
@@ -0,0 +1,142 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/sillyhatxu/db-client/builder"
+	"github.com/sillyhatxu/db-client/dbclient"
+	"github.com/sillyhatxu/db-client/structs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func insertUser(session Session, loginName string) (int64, error) {
+	user := User{
+		LoginName:        loginName,
+		Password:         "Password",
+		UserName:         "UserName",
+		Status:           true,
+		Platform:         "Platform",
+		CreatedTime:      time.Now(),
+		LastModifiedTime: time.Now(),
+	}
+	sql, args, err := builder.BuildInsert("user", []map[string]interface{}{structs.New(user).Map()})
+	if err != nil {
+		return 0, err
+	}
+	return session.Insert(sql, args...)
+}
+
+func TestMysqlClient_WithTx(t *testing.T) {
+	once.Do(setup)
+	var id int64
+	err := mysqlClient.WithTx(func(ctx context.Context, tx *Tx) error {
+		var err error
+		id, err = insertUser(tx, "WithTx")
+		if err != nil {
+			return err
+		}
+		var user User
+		err = tx.FindFirst("select * from user where id = ?", &user, id)
+		assert.Nil(t, err)
+		assert.EqualValues(t, "WithTx", user.LoginName)
+		return nil
+	})
+	assert.Nil(t, err)
+	count, err := mysqlClient.Count("select count(1) from user where id = ?", id)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestMysqlClient_WithTxSingleConnection(t *testing.T) {
+	pool, err := dbclient.NewDBClient(
+		dbclient.UserName(userName),
+		dbclient.Password(password),
+		dbclient.Host(host),
+		dbclient.Port(port),
+		dbclient.Schema(schema),
+		dbclient.MaxOpenConns(1),
+	)
+	assert.Nil(t, err)
+	mc, err := NewMysqlClient(Pool(pool), Timeout(5*time.Second))
+	assert.Nil(t, err)
+	defer mc.Close(context.Background())
+	for i := 0; i < 2; i++ {
+		err = mc.WithTx(func(ctx context.Context, tx *Tx) error {
+			if _, err := tx.Exec("update user set status = status where id = ?", 1); err != nil {
+				return err
+			}
+			_, err := tx.FindMapArray("select * from user where id = ?", 1)
+			return err
+		})
+		assert.Nil(t, err)
+	}
+}
+
+func TestMysqlClient_NestedTransaction(t *testing.T) {
+	once.Do(setup)
+	var outerId, innerId int64
+	err := mysqlClient.WithTx(func(ctx context.Context, tx *Tx) error {
+		var err error
+		outerId, err = insertUser(tx, "Outer")
+		if err != nil {
+			return err
+		}
+		err = mysqlClient.WithTxContext(ctx, func(ctx context.Context, tx *Tx) error {
+			innerId, err = insertUser(tx, "Inner")
+			if err != nil {
+				return err
+			}
+			return errors.New("rollback inner")
+		})
+		assert.NotNil(t, err)
+		return nil
+	})
+	assert.Nil(t, err)
+	count, err := mysqlClient.Count("select count(1) from user where id in (?, ?)", outerId, innerId)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestMysqlClient_NestedTransactionCallback(t *testing.T) {
+	once.Do(setup)
+	var outerId, innerId int64
+	err := mysqlClient.Transaction(func(ctx context.Context, outer *sql.Tx) error {
+		tx, ok := TxFromContext(ctx)
+		assert.True(t, ok)
+		var err error
+		outerId, err = insertUser(tx, "OuterCallback")
+		if err != nil {
+			return err
+		}
+		err = mysqlClient.TransactionContext(ctx, func(ctx context.Context, inner *sql.Tx) error {
+			assert.True(t, inner == outer)
+			tx, _ := TxFromContext(ctx)
+			innerId, err = insertUser(tx, "InnerCallback")
+			if err != nil {
+				return err
+			}
+			return errors.New("rollback inner")
+		})
+		assert.NotNil(t, err)
+		return nil
+	})
+	assert.Nil(t, err)
+	count, err := mysqlClient.Count("select count(1) from user where id in (?, ?)", outerId, innerId)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestMysqlClient_ReadOnlyTransaction(t *testing.T) {
+	once.Do(setup)
+	err := mysqlClient.WithTx(func(ctx context.Context, tx *Tx) error {
+		count, err := tx.Count("select count(1) from user")
+		assert.Nil(t, err)
+		assert.True(t, count > 0)
+		_, err = insertUser(tx, "ReadOnly")
+		return err
+	}, Isolation(sql.LevelRepeatableRead), ReadOnly(true), TxTimeout(5*time.Second))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,80 @@
+package mysqlclient
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type txConfig struct {
+	options  sql.TxOptions
+	timeout  time.Duration
+	retry    *RetryPolicy
+	attempts *int
+}
+
+type TxOption func(*txConfig)
+
+// Isolation sets the isolation level, e.g. sql.LevelRepeatableRead.
+func Isolation(level sql.IsolationLevel) TxOption {
+	return func(c *txConfig) {
+		c.options.Isolation = level
+	}
+}
+
+func ReadOnly(readOnly bool) TxOption {
+	return func(c *txConfig) {
+		c.options.ReadOnly = readOnly
+	}
+}
+
+// TxTimeout bounds the whole transaction. Without it the transaction uses
+// the client Timeout, or only the caller's context for the Context variants.
+func TxTimeout(timeout time.Duration) TxOption {
+	return func(c *txConfig) {
+		c.timeout = timeout
+	}
+}
+
+// Retry overrides the TxRetry policy of the client for one transaction.
+func Retry(policy RetryPolicy) TxOption {
+	return func(c *txConfig) {
+		c.retry = &policy
+	}
+}
+
+// Attempts stores the number of times the transaction ran into attempts.
+func Attempts(attempts *int) TxOption {
+	return func(c *txConfig) {
+		c.attempts = attempts
+	}
+}
+
+func newTxConfig(opts []TxOption) *txConfig {
+	config := &txConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// getTxContext returns the context used by the transaction methods without
+// a context argument.
+func (mc *MysqlClient) getTxContext(config *txConfig) (context.Context, context.CancelFunc) {
+	if config.timeout > 0 {
+		return context.WithTimeout(context.Background(), config.timeout)
+	}
+	return mc.getContext()
+}
+
+func (mc *MysqlClient) TransactionWithOptions(callback TransactionCallback, opts ...TxOption) error {
+	ctx, cancel := mc.getTxContext(newTxConfig(opts))
+	defer cancel()
+	return mc.TransactionWithOptionsContext(ctx, callback, opts...)
+}
+
+func (mc *MysqlClient) TransactionWithOptionsContext(ctx context.Context, callback TransactionCallback, opts ...TxOption) error {
+	return mc.WithTxContext(ctx, func(ctx context.Context, tx *Tx) error {
+		return callback(ctx, tx.Raw())
+	}, opts...)
+}
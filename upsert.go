@@ -0,0 +1,78 @@
+package mysqlclient
+
+import (
+	"context"
+	"fmt"
+	"github.com/sillyhatxu/db-client/builder"
+)
+
+// UpsertResult is what INSERT ... ON DUPLICATE KEY UPDATE did to a row.
+type UpsertResult int
+
+const (
+	// UpsertUnchanged means the row existed with the values of the update.
+	UpsertUnchanged UpsertResult = iota
+	UpsertInserted
+	UpsertUpdated
+)
+
+func (r UpsertResult) String() string {
+	switch r {
+	case UpsertInserted:
+		return "inserted"
+	case UpsertUpdated:
+		return "updated"
+	}
+	return "unchanged"
+}
+
+// Upsert inserts every row, or updates it as described by update when it
+// hits a unique key, see builder.BuildUpsert. The rows are upserted one by
+// one in a transaction so that each result can be told apart from the
+// affected rows: 1 inserted, 2 updated, 0 unchanged. The last one needs the
+// default clientFoundRows=false of dbclient, with it on unchanged rows are
+// reported as inserted.
+func (mc *MysqlClient) Upsert(table string, rows []map[string]interface{}, update map[string]interface{}) ([]UpsertResult, error) {
+	ctx, cancel := mc.getContext()
+	defer cancel()
+	return mc.UpsertContext(ctx, table, rows, update)
+}
+
+func (mc *MysqlClient) UpsertContext(ctx context.Context, table string, rows []map[string]interface{}, update map[string]interface{}) ([]UpsertResult, error) {
+	var results []UpsertResult
+	err := mc.WithTxContext(ctx, func(ctx context.Context, tx *Tx) error {
+		results = make([]UpsertResult, 0, len(rows))
+		for _, row := range rows {
+			sql, args, err := builder.BuildUpsert(table, []map[string]interface{}{row}, update)
+			if err != nil {
+				return err
+			}
+			rowsAffected, err := tx.UpdateContext(ctx, sql, args...)
+			if err != nil {
+				return err
+			}
+			result, err := toUpsertResult(rowsAffected)
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func toUpsertResult(rowsAffected int64) (UpsertResult, error) {
+	switch rowsAffected {
+	case 0:
+		return UpsertUnchanged, nil
+	case 1:
+		return UpsertInserted, nil
+	case 2:
+		return UpsertUpdated, nil
+	}
+	return 0, fmt.Errorf("unexpected rows affected by upsert: %d", rowsAffected)
+}
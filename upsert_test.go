@@ -0,0 +1,25 @@
+package mysqlclient
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestToUpsertResult(t *testing.T) {
+	var testCases = []struct {
+		rowsAffected int64
+		expected     UpsertResult
+		hasErr       bool
+	}{
+		{rowsAffected: 0, expected: UpsertUnchanged},
+		{rowsAffected: 1, expected: UpsertInserted},
+		{rowsAffected: 2, expected: UpsertUpdated},
+		{rowsAffected: 3, hasErr: true},
+	}
+	for _, test := range testCases {
+		result, err := toUpsertResult(test.rowsAffected)
+		assert.Equal(t, test.hasErr, err != nil)
+		assert.Equal(t, test.expected, result)
+	}
+	assert.Equal(t, "updated", UpsertUpdated.String())
+}